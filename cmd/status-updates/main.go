@@ -10,6 +10,9 @@ import (
 	"github.com/TicketsBot-cloud/status-updates/internal/daemon"
 	"github.com/TicketsBot-cloud/status-updates/internal/db"
 	"github.com/TicketsBot-cloud/status-updates/internal/httpserver"
+	"github.com/TicketsBot-cloud/status-updates/internal/i18n"
+	"github.com/TicketsBot-cloud/status-updates/internal/model"
+	"github.com/TicketsBot-cloud/status-updates/internal/source"
 	"github.com/TicketsBot-cloud/status-updates/internal/statuspage"
 	"go.uber.org/zap"
 
@@ -26,12 +29,33 @@ func main() {
 	logger, _ := zap.NewProduction()
 	defer logger.Sync()
 
+	if err := i18n.Load(conf.LocalesDir); err != nil {
+		logger.Fatal("Failed to load translations", zap.Error(err))
+	}
+
+	if err := model.LoadTemplates(conf.Templates); err != nil {
+		logger.Fatal("Failed to load templates", zap.Error(err))
+	}
+
 	if err := db.InitDB(); err != nil {
 		logger.Fatal("Failed to initialize database", zap.Error(err))
 	}
 
-	statusPageClient := statuspage.NewClient(logger)
-	daemon := daemon.NewDaemon(logger, statusPageClient)
+	feeds := make([]daemon.Feed, 0, len(conf.Feeds))
+	sources := make([]source.IncidentSource, 0, len(conf.Feeds))
+	for _, feed := range conf.Feeds {
+		var incidentSource source.IncidentSource
+		if feed.Mode == config.StatusPageModePublic {
+			incidentSource = statuspage.NewPublicClient(logger.With(zap.String("feed", feed.Name)), feed)
+		} else {
+			incidentSource = statuspage.NewClient(logger.With(zap.String("feed", feed.Name)), feed)
+		}
+
+		feeds = append(feeds, daemon.Feed{Config: feed, Source: incidentSource})
+		sources = append(sources, incidentSource)
+	}
+
+	daemon := daemon.NewDaemon(logger, feeds...)
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
@@ -42,7 +66,13 @@ func main() {
 		}
 	}()
 
-	server := httpserver.NewServer(logger.With(zap.String("component", "server")), conf)
+	server := httpserver.NewServer(ctx, logger.With(zap.String("component", "server")), conf, daemon, sources...)
+
+	if conf.Discord.RegisterCommands {
+		if err := server.RegisterCommands(ctx); err != nil {
+			logger.Error("Failed to register application commands", zap.Error(err))
+		}
+	}
 
 	go func() {
 		logger.Info("Starting HTTP server on :8080")
@@ -1,12 +1,14 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/caarlos0/env/v11"
 	"go.uber.org/zap/zapcore"
+	"golang.org/x/text/language"
 )
 
 // Config holds the configuration values for the application.
@@ -15,23 +17,74 @@ type Config struct {
 		Enabled          bool          `env:"ENABLED" envDefault:"false"`
 		Frequency        time.Duration `env:"FREQUENCY" envDefault:"30s"`
 		ExecutionTimeout time.Duration `env:"EXECUTION_TIMEOUT" envDefault:"30m"`
+		// DeepSyncFrequency is how often every page of incidents is fetched, rather than only recent incidents
+		DeepSyncFrequency time.Duration `env:"DEEP_SYNC_FREQUENCY" envDefault:"1h"`
+		// ComponentDebounce is how long a component must keep a new status before the change is announced
+		ComponentDebounce time.Duration `env:"COMPONENT_DEBOUNCE" envDefault:"5m"`
 	} `envPrefix:"DAEMON_"`
 
 	Discord struct {
-		Token           string `env:"TOKEN,required"`
-		PublicKey       string `env:"PUBLIC_KEY,required"`
-		GuildId         uint64 `env:"GUILD_ID,required"`
-		ChannelId       uint64 `env:"CHANNEL_ID,required"`
-		UpdateRoleId    uint64 `env:"UPDATE_ROLE_ID,required"`
+		Token     string `env:"TOKEN,required"`
+		PublicKey string `env:"PUBLIC_KEY,required"`
+		GuildId   uint64 `env:"GUILD_ID,required"`
+		// ApplicationId is used to register application commands. If unset, it is looked up using the bot token.
+		ApplicationId    uint64 `env:"APPLICATION_ID"`
+		RegisterCommands bool   `env:"REGISTER_COMMANDS" envDefault:"true"`
+		// StaffRoleId is the role in GuildId allowed to create and update incidents from Discord. The incident
+		// commands are disabled if unset.
+		StaffRoleId uint64 `env:"STAFF_ROLE_ID"`
+		// ChannelId, UpdateRoleId and ShouldCrosspost configure the default feed, used if no feeds are configured
+		ChannelId       uint64 `env:"CHANNEL_ID"`
+		UpdateRoleId    uint64 `env:"UPDATE_ROLE_ID"`
 		ShouldCrosspost bool   `env:"SHOULD_CROSSPOST" envDefault:"true"`
+		// ComponentChannelId configures the default feed's component status channel
+		ComponentChannelId uint64 `env:"COMPONENT_CHANNEL_ID"`
+		// StatusBoardChannelId configures the default feed's status board channel
+		StatusBoardChannelId uint64 `env:"STATUS_BOARD_CHANNEL_ID"`
+		// Locale configures the default feed's locale
+		Locale string `env:"LOCALE"`
 	} `envPrefix:"DISCORD_"`
 
 	StatusPage struct {
-		ApiKey string `env:"API_KEY,required"`
-		PageId string `env:"PAGE_ID,required"`
+		// Mode, ApiKey, PageId and Url configure the default feed, used if no feeds are configured
+		Mode   string `env:"MODE" envDefault:"manage"`
+		ApiKey string `env:"API_KEY"`
+		PageId string `env:"PAGE_ID"`
 		Url    string `env:"URL" envDefault:"status.ticketsbot.cloud"`
+		// WebhookSecret is the shared secret Statuspage webhooks must present. The webhook endpoint is disabled if unset.
+		WebhookSecret string `env:"WEBHOOK_SECRET"`
+
+		RequestTimeout time.Duration `env:"REQUEST_TIMEOUT" envDefault:"10s"`
+		MaxRetries     int           `env:"MAX_RETRIES" envDefault:"3"`
+		// CircuitBreakerThreshold is the number of consecutive failures after which requests are paused
+		CircuitBreakerThreshold int           `env:"CIRCUIT_BREAKER_THRESHOLD" envDefault:"5"`
+		CircuitBreakerCooldown  time.Duration `env:"CIRCUIT_BREAKER_COOLDOWN" envDefault:"5m"`
 	} `envPrefix:"STATUSPAGE_"`
 
+	DirectMessages struct {
+		// RateLimit is the most direct messages sent to a single user per RateLimitWindow. Further updates are
+		// delivered once the window has passed.
+		RateLimit       int           `env:"RATE_LIMIT" envDefault:"5"`
+		RateLimitWindow time.Duration `env:"RATE_LIMIT_WINDOW" envDefault:"10m"`
+		// MaxFailures is the number of consecutive failed deliveries, such as to a user with closed DMs, after which
+		// direct messages are disabled for the user
+		MaxFailures int `env:"MAX_FAILURES" envDefault:"3"`
+	} `envPrefix:"DM_"`
+
+	// Severities configures the labels, colors and emoji used for each component status and incident severity
+	Severities Severities `envPrefix:"SEVERITY_"`
+
+	// LocalesDir is the directory holding translation files, named after their locale, such as de.json
+	LocalesDir string `env:"LOCALES_DIR"`
+
+	// Templates configures the text/template files used to render incident messages. The built-in template is used
+	// for any that are unset.
+	Templates Templates `envPrefix:"TEMPLATE_"`
+
+	// Feeds routes the incidents of each status page to its own Discord channel. If empty, a single feed is built
+	// from the Discord and StatusPage settings above.
+	Feeds []Feed `envPrefix:"FEEDS_"`
+
 	ServerAddr string `env:"SERVER_ADDR" envDefault:":8080"`
 
 	DatabaseUri string `env:"DATABASE_URI"`
@@ -40,19 +93,191 @@ type Config struct {
 	LogLevel zapcore.Level `env:"LOG_LEVEL" envDefault:"info"`
 }
 
+// Feed maps a single status page to the Discord channel its incidents are announced in.
+type Feed struct {
+	// Name uniquely identifies the feed. Incidents are tagged with the name of the feed they were announced by.
+	Name string `env:"NAME"`
+
+	// Mode selects how incidents are read: "manage" uses the authenticated manage API for a page we own, while
+	// "public" reads the public API of any status page at Url, without an API key.
+	Mode   string `env:"MODE" envDefault:"manage"`
+	ApiKey string `env:"API_KEY"`
+	PageId string `env:"PAGE_ID"`
+	Url    string `env:"URL"`
+	// Components limits the feed to incidents affecting at least one of the given component IDs
+	Components []string `env:"COMPONENTS"`
+
+	ChannelId       uint64 `env:"CHANNEL_ID"`
+	UpdateRoleId    uint64 `env:"UPDATE_ROLE_ID"` // UpdateRoleId is mentioned on new incidents, if set
+	ShouldCrosspost bool   `env:"SHOULD_CROSSPOST" envDefault:"true"`
+	// ThreadAutoArchiveDuration is the number of minutes of inactivity after which incident threads are archived
+	ThreadAutoArchiveDuration uint16 `env:"THREAD_AUTO_ARCHIVE_DURATION" envDefault:"1440"`
+	// KeepThreadsOpen leaves incident threads unarchived and unlocked once an incident is resolved
+	KeepThreadsOpen bool `env:"KEEP_THREADS_OPEN"`
+	// ComponentChannelId is the channel component status changes are announced in. Component statuses are not
+	// tracked if unset.
+	ComponentChannelId uint64 `env:"COMPONENT_CHANNEL_ID"`
+	// StatusBoardChannelId is the channel the status board, a pinned message showing the current status of every
+	// component and any active incidents, is kept in. No status board is kept if unset.
+	StatusBoardChannelId uint64 `env:"STATUS_BOARD_CHANNEL_ID"`
+	// Locale is the locale messages are posted in, such as de or pt-BR. Partner servers may use their own locale.
+	Locale string `env:"LOCALE" envDefault:"en"`
+}
+
+// Templates holds the paths of the text/template files used to render incident messages
+type Templates struct {
+	// Announcement renders the incident announcement, and is kept up to date as the incident changes
+	Announcement string `env:"ANNOUNCEMENT"`
+	// Update renders each incident update posted to the incident thread
+	Update string `env:"UPDATE"`
+	// Resolution renders the message posted to the incident thread once the incident is resolved
+	Resolution string `env:"RESOLUTION"`
+	// Mention renders the line posted above announcements and updates, mentioning the role to notify
+	Mention string `env:"MENTION"`
+}
+
+const (
+	StatusPageModeManage = "manage"
+	StatusPageModePublic = "public"
+
+	// DefaultFeedName is the name of the feed built from the legacy settings. It matches the name incidents were tagged
+	// with before feeds were introduced, so previously announced incidents continue to be tracked.
+	DefaultFeedName = "statuspage"
+	// DefaultPublicFeedName is the name of the feed built from the legacy settings in public mode
+	DefaultPublicFeedName = "statuspage-public"
+)
+
 var Conf Config
 
 func LoadConfig() (Config, error) {
-	if _, err := os.Stat("config.toml"); err == nil {
-		return fromToml()
+	var config Config
+	var err error
+	if _, statErr := os.Stat("config.toml"); statErr == nil {
+		config, err = fromToml()
 	} else {
-		return fromEnvvar()
+		config, err = fromEnvvar()
+	}
+
+	if err != nil {
+		return Config{}, err
 	}
+
+	config.normalizeFeeds()
+	if err := config.validate(); err != nil {
+		return Config{}, err
+	}
+
+	return config, nil
+}
+
+// normalizeFeeds builds the default feed from the legacy settings if no feeds are configured, and fills in defaults.
+func (c *Config) normalizeFeeds() {
+	if len(c.Feeds) == 0 {
+		name := DefaultFeedName
+		if c.StatusPage.Mode == StatusPageModePublic {
+			name = DefaultPublicFeedName
+		}
+
+		c.Feeds = []Feed{{
+			Name:                 name,
+			Mode:                 c.StatusPage.Mode,
+			ApiKey:               c.StatusPage.ApiKey,
+			PageId:               c.StatusPage.PageId,
+			Url:                  c.StatusPage.Url,
+			ChannelId:            c.Discord.ChannelId,
+			UpdateRoleId:         c.Discord.UpdateRoleId,
+			ShouldCrosspost:      c.Discord.ShouldCrosspost,
+			ComponentChannelId:   c.Discord.ComponentChannelId,
+			StatusBoardChannelId: c.Discord.StatusBoardChannelId,
+			Locale:               c.Discord.Locale,
+		}}
+	}
+
+	for i := range c.Feeds {
+		feed := &c.Feeds[i]
+		if feed.Mode == "" {
+			feed.Mode = StatusPageModeManage
+		}
+
+		if feed.Name == "" {
+			if feed.PageId != "" {
+				feed.Name = feed.PageId
+			} else {
+				feed.Name = feed.Url
+			}
+		}
+
+		if feed.ThreadAutoArchiveDuration == 0 {
+			feed.ThreadAutoArchiveDuration = 1440 // 24 hours
+		}
+
+		if feed.Locale == "" {
+			feed.Locale = "en"
+		}
+	}
+}
+
+func (c Config) validate() error {
+	if err := c.Severities.validate(); err != nil {
+		return err
+	}
+
+	names := make(map[string]bool, len(c.Feeds))
+	pageChannels := make(map[string]bool, len(c.Feeds))
+	for i, feed := range c.Feeds {
+		if names[feed.Name] {
+			return fmt.Errorf("feed %d: duplicate feed name %q", i, feed.Name)
+		}
+		names[feed.Name] = true
+
+		switch feed.Mode {
+		case StatusPageModeManage:
+			if feed.ApiKey == "" || feed.PageId == "" {
+				return fmt.Errorf("feed %q: an API key and page ID are required in manage mode", feed.Name)
+			}
+
+		case StatusPageModePublic:
+			if feed.Url == "" {
+				return fmt.Errorf("feed %q: a URL is required in public mode", feed.Name)
+			}
+
+		default:
+			return fmt.Errorf("feed %q: unknown mode %q", feed.Name, feed.Mode)
+		}
+
+		if feed.ChannelId == 0 {
+			return fmt.Errorf("feed %q: a channel ID is required", feed.Name)
+		}
+
+		// Deliveries to a channel are tracked by incident, so feeds splitting a page must use their own channels
+		pageChannel := fmt.Sprintf("%s/%s/%d", feed.PageId, feed.Url, feed.ChannelId)
+		if pageChannels[pageChannel] {
+			return fmt.Errorf("feed %q: channel %d already announces this page for another feed", feed.Name, feed.ChannelId)
+		}
+		pageChannels[pageChannel] = true
+
+		if _, err := language.Parse(feed.Locale); err != nil {
+			return fmt.Errorf("feed %q: invalid locale %q: %w", feed.Name, feed.Locale, err)
+		}
+	}
+
+	return nil
+}
+
+// FeedByName returns the feed with the given name.
+func (c Config) FeedByName(name string) (Feed, bool) {
+	for _, feed := range c.Feeds {
+		if feed.Name == name {
+			return feed, true
+		}
+	}
+
+	return Feed{}, false
 }
 
 func fromToml() (Config, error) {
 	var config Config
-	if _, err := toml.DecodeFile("config.toml", &Conf); err != nil {
+	if _, err := toml.DecodeFile("config.toml", &config); err != nil {
 		return Config{}, err
 	}
 
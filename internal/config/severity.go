@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Severity configures how a component status, and incidents of the equivalent severity, are displayed. Empty fields
+// fall back to the defaults.
+type Severity struct {
+	Label string `env:"LABEL"`
+	// Color is the accent color as a hex string, such as #FFA500
+	Color string `env:"COLOR"`
+	Emoji string `env:"EMOJI"`
+}
+
+// Severities configures the display of each component status
+type Severities struct {
+	Operational         Severity `envPrefix:"OPERATIONAL_"`
+	DegradedPerformance Severity `envPrefix:"DEGRADED_PERFORMANCE_"`
+	PartialOutage       Severity `envPrefix:"PARTIAL_OUTAGE_"`
+	MajorOutage         Severity `envPrefix:"MAJOR_OUTAGE_"`
+	UnderMaintenance    Severity `envPrefix:"UNDER_MAINTENANCE_"`
+	// Unknown is used for unrecognised statuses, and incidents with no impact or affected components
+	Unknown Severity `envPrefix:"UNKNOWN_"`
+}
+
+var defaultSeverities = map[string]Severity{
+	"operational":          {Label: "Operational", Color: "#00CD00", Emoji: "🟢"},
+	"degraded_performance": {Label: "Degraded Performance", Color: "#FFA500", Emoji: "🟡"},
+	"partial_outage":       {Label: "Partial Outage", Color: "#FFA500", Emoji: "🟠"},
+	"major_outage":         {Label: "Major Outage", Color: "#FF0000", Emoji: "🔴"},
+	"under_maintenance":    {Label: "Under Maintenance", Color: "#3498DB", Emoji: "🔵"},
+	"":                     {Label: "Unknown", Color: "#00CD00", Emoji: "⚪"},
+}
+
+// byStatus returns the configured severities keyed by component status. Unknown is keyed by the empty string.
+func (s Severities) byStatus() map[string]Severity {
+	return map[string]Severity{
+		"operational":          s.Operational,
+		"degraded_performance": s.DegradedPerformance,
+		"partial_outage":       s.PartialOutage,
+		"major_outage":         s.MajorOutage,
+		"under_maintenance":    s.UnderMaintenance,
+		"":                     s.Unknown,
+	}
+}
+
+// Severity returns how the given component status is displayed, falling back to the defaults for any field that is
+// not configured.
+func (c Config) Severity(status string) Severity {
+	severity, ok := defaultSeverities[status]
+	if !ok {
+		status = ""
+		severity = defaultSeverities[status]
+	}
+
+	custom := c.Severities.byStatus()[status]
+	if custom.Label != "" {
+		severity.Label = custom.Label
+	}
+
+	if custom.Color != "" {
+		severity.Color = custom.Color
+	}
+
+	if custom.Emoji != "" {
+		severity.Emoji = custom.Emoji
+	}
+
+	return severity
+}
+
+// ColorValue returns the color as an integer, as used by Discord. Colors are validated when the config is loaded.
+func (s Severity) ColorValue() int {
+	color, _ := parseColor(s.Color)
+	return color
+}
+
+func parseColor(color string) (int, error) {
+	value, err := strconv.ParseUint(strings.TrimPrefix(color, "#"), 16, 24)
+	if err != nil {
+		return 0, fmt.Errorf("invalid color %q, expected a hex color such as #FFA500", color)
+	}
+
+	return int(value), nil
+}
+
+func (s Severities) validate() error {
+	for status, severity := range s.byStatus() {
+		if severity.Color == "" {
+			continue
+		}
+
+		if _, err := parseColor(severity.Color); err != nil {
+			if status == "" {
+				status = "unknown"
+			}
+			return fmt.Errorf("severity %s: %w", status, err)
+		}
+	}
+
+	return nil
+}
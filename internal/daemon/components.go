@@ -0,0 +1,134 @@
+package daemon
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TicketsBot-cloud/gdl/objects/channel/message"
+	"github.com/TicketsBot-cloud/gdl/objects/interaction/component"
+	"github.com/TicketsBot-cloud/gdl/rest"
+	"github.com/TicketsBot-cloud/status-updates/internal/model"
+	"go.uber.org/zap"
+)
+
+// refreshComponents fetches the components of the feed, if needed, to announce their status changes and keep the
+// status board up to date.
+func (d *Daemon) refreshComponents(ctx context.Context, feed Feed, incidents []model.Incident) error {
+	if feed.Config.ComponentChannelId == 0 && feed.Config.StatusBoardChannelId == 0 {
+		return nil
+	}
+
+	components, err := feed.Source.ListComponents(ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching components: %w", err)
+	}
+
+	return errors.Join(
+		d.checkComponents(ctx, feed, components),
+		d.updateStatusBoard(ctx, feed, components, incidents),
+	)
+}
+
+// checkComponents compares the status of every component of the feed against its last known status, announcing any
+// change that has persisted for the configured debounce period. Changes that revert before then are never announced.
+// Components seen for the first time are recorded without being announced, and components outside of the feed's
+// configured components are ignored, as they are for incidents.
+func (d *Daemon) checkComponents(ctx context.Context, feed Feed, components []model.Component) error {
+	if feed.Config.ComponentChannelId == 0 {
+		return nil
+	}
+
+	known, err := model.GetComponentStatuses(feed.Config.Name)
+	if err != nil {
+		return fmt.Errorf("error retrieving component statuses: %w", err)
+	}
+
+	home := d.homeSubscription(feed)
+	now := time.Now()
+	for _, c := range components {
+		// Groups only reflect the status of their components, which are announced individually
+		if c.Group || !home.MatchesComponents([]string{c.ID}) {
+			continue
+		}
+
+		state, ok := known[c.ID]
+		if !ok {
+			state = model.ComponentStatus{
+				Feed:        feed.Config.Name,
+				ComponentId: c.ID,
+				Status:      c.Status,
+				UpdatedAt:   now,
+			}
+			if err := state.Save(); err != nil {
+				return fmt.Errorf("error saving component status: %w", err)
+			}
+			continue
+		}
+
+		if c.Status == state.Status {
+			// The component flapped back before the change was announced
+			if state.PendingStatus != "" {
+				state.PendingStatus = ""
+				state.PendingSince = sql.NullTime{}
+				state.UpdatedAt = now
+				if err := state.Save(); err != nil {
+					return fmt.Errorf("error saving component status: %w", err)
+				}
+			}
+			continue
+		}
+
+		if state.PendingStatus != c.Status {
+			state.PendingStatus = c.Status
+			state.PendingSince = sql.NullTime{Time: now, Valid: true}
+			state.UpdatedAt = now
+			if err := state.Save(); err != nil {
+				return fmt.Errorf("error saving component status: %w", err)
+			}
+		}
+
+		if now.Sub(state.PendingSince.Time) < d.config.Daemon.ComponentDebounce {
+			continue
+		}
+
+		if err := d.announceComponentChange(ctx, feed, c, state.Status); err != nil {
+			d.logger.Error("Error announcing component status change", zap.Error(err),
+				zap.String("feed", feed.Config.Name),
+				zap.String("component_id", c.ID),
+			)
+			continue
+		}
+
+		state.Status = c.Status
+		state.PendingStatus = ""
+		state.PendingSince = sql.NullTime{}
+		state.UpdatedAt = now
+		if err := state.Save(); err != nil {
+			return fmt.Errorf("error saving component status: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// announceComponentChange posts that the component changed from the previous status to its current status.
+func (d *Daemon) announceComponentChange(ctx context.Context, feed Feed, c model.Component, previous string) error {
+	_, err := d.discord.CreateMessage(ctx, feed.Config.ComponentChannelId, rest.CreateMessageData{
+		Components: []component.Component{c.GenerateStatusChangeContainer(previous, feed.Config.Locale)},
+		Flags:      message.SumFlags(message.FlagComponentsV2),
+	})
+	if err != nil {
+		return err
+	}
+
+	d.logger.Info("Component status change announced",
+		zap.String("feed", feed.Config.Name),
+		zap.String("component_id", c.ID),
+		zap.String("from", previous),
+		zap.String("to", c.Status),
+	)
+	return nil
+}
@@ -2,36 +2,109 @@ package daemon
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
-	"github.com/TicketsBot-cloud/gdl/objects/channel"
 	"github.com/TicketsBot-cloud/gdl/objects/channel/message"
 	"github.com/TicketsBot-cloud/gdl/objects/interaction/component"
 	"github.com/TicketsBot-cloud/gdl/rest"
+	"github.com/TicketsBot-cloud/gdl/rest/request"
 	"github.com/TicketsBot-cloud/status-updates/internal/config"
 	"github.com/TicketsBot-cloud/status-updates/internal/model"
-	"github.com/TicketsBot-cloud/status-updates/internal/statuspage"
+	"github.com/TicketsBot-cloud/status-updates/internal/source"
 	"go.uber.org/zap"
 )
 
 type Daemon struct {
-	logger           *zap.Logger
-	config           config.Config
-	statusPageClient statuspage.StatusPageClient
+	logger       *zap.Logger
+	config       config.Config
+	feeds        []Feed
+	mu           sync.Mutex       // mu serialises incident processing between the polling loop and webhooks
+	trigger      chan struct{}    // trigger requests an immediate run
+	lastDeepSync time.Time        // lastDeepSync is when every page of incidents was last fetched
+	dmLimiter    *dmLimiter       // dmLimiter rate limits direct messages per user
+	dmJobs       chan dmJob       // dmJobs holds the incidents whose direct messages are waiting to be sent
+	tracked      trackedIncidents // tracked looks up the incidents each feed has announced
+	notices      sentNotices      // notices records the one-off notices posted to each destination
+	discord      discordClient    // discord is used for every call to the Discord API
 }
 
-func NewDaemon(logger *zap.Logger, spc statuspage.StatusPageClient) *Daemon {
+// trackedIncidents looks up the incidents that have been announced for a feed, so that they can be followed until they
+// are resolved.
+type trackedIncidents interface {
+	GetIncidentIds(source string) ([]string, error)
+	GetUnresolvedIncidentIds(source string) ([]string, error)
+	MarkIncidentMissing(source, incidentId string) error
+}
+
+// dbTrackedIncidents looks up tracked incidents in the database.
+type dbTrackedIncidents struct{}
+
+func (dbTrackedIncidents) GetIncidentIds(source string) ([]string, error) {
+	return model.GetIncidentIds(source)
+}
+
+func (dbTrackedIncidents) GetUnresolvedIncidentIds(source string) ([]string, error) {
+	return model.GetUnresolvedIncidentIds(source)
+}
+
+func (dbTrackedIncidents) MarkIncidentMissing(source, incidentId string) error {
+	return model.MarkIncidentMissing(source, incidentId)
+}
+
+// sentNotices records the one-off notices, such as maintenance reminders and postmortems, posted to each destination.
+type sentNotices interface {
+	SentNotices(dest model.IncidentDestination) (map[string]bool, error)
+	MarkNoticeSent(dest model.IncidentDestination, notice string) error
+}
+
+// dbSentNotices records sent notices in the database.
+type dbSentNotices struct{}
+
+func (dbSentNotices) SentNotices(dest model.IncidentDestination) (map[string]bool, error) {
+	return dest.SentNotices()
+}
+
+func (dbSentNotices) MarkNoticeSent(dest model.IncidentDestination, notice string) error {
+	return dest.MarkNoticeSent(notice)
+}
+
+// Feed pairs an incident source with the configuration of the Discord channel its incidents are announced in.
+type Feed struct {
+	Config config.Feed
+	Source source.IncidentSource
+}
+
+// pageId returns the page the incident belongs to, falling back to the feed's page for sources that don't report it.
+func (f Feed) pageId(incident model.Incident) string {
+	if incident.PageID != "" {
+		return incident.PageID
+	}
+
+	return f.Config.PageId
+}
+
+func NewDaemon(logger *zap.Logger, feeds ...Feed) *Daemon {
 	return &Daemon{
-		logger:           logger,
-		config:           config.Conf,
-		statusPageClient: spc,
+		logger:    logger,
+		config:    config.Conf,
+		feeds:     feeds,
+		trigger:   make(chan struct{}, 1),
+		dmJobs:    make(chan dmJob, dmQueueSize),
+		dmLimiter: newDmLimiter(config.Conf.DirectMessages.RateLimit, config.Conf.DirectMessages.RateLimitWindow),
+		tracked:   dbTrackedIncidents{},
+		notices:   dbSentNotices{},
+		discord:   restClient{token: config.Conf.Discord.Token},
 	}
 }
 
 func (d *Daemon) Start(ctx context.Context) error {
 	d.logger.Info("Starting daemon")
 
+	go d.runDirectMessages(ctx)
+
 	// Run once immediately to avoid waiting for the first timer tick
 	if err := d.runOnce(ctx); err != nil {
 		d.logger.Error("Failed to run initial check", zap.Error(err))
@@ -43,12 +116,11 @@ func (d *Daemon) Start(ctx context.Context) error {
 	for {
 		select {
 		case <-timer.C:
-			start := time.Now()
-			d.logger.Info("Run started", zap.Time("start_time", start))
-			if err := d.runOnce(ctx); err != nil {
-				d.logger.Error("Failed to run", zap.Error(err))
-			}
-			d.logger.Info("Run completed", zap.Time("end_time", time.Now()), zap.Duration("duration", time.Since(start)))
+			d.run(ctx)
+			timer.Reset(d.config.Daemon.Frequency)
+		case <-d.trigger:
+			d.logger.Info("Run triggered")
+			d.run(ctx)
 			timer.Reset(d.config.Daemon.Frequency)
 		case <-ctx.Done():
 			d.logger.Info("Shutting down daemon")
@@ -58,175 +130,609 @@ func (d *Daemon) Start(ctx context.Context) error {
 	}
 }
 
+func (d *Daemon) run(ctx context.Context) {
+	start := time.Now()
+	d.logger.Info("Run started", zap.Time("start_time", start))
+	if err := d.runOnce(ctx); err != nil {
+		d.logger.Error("Failed to run", zap.Error(err))
+	}
+	d.logger.Info("Run completed", zap.Time("end_time", time.Now()), zap.Duration("duration", time.Since(start)))
+}
+
 func (d *Daemon) runOnce(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, d.config.Daemon.ExecutionTimeout)
 	defer cancel()
 
-	incidents, err := d.statusPageClient.GetIncidents()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	deepSync := time.Since(d.lastDeepSync) >= d.config.Daemon.DeepSyncFrequency
+	if deepSync {
+		d.logger.Info("Performing deep sync")
+	}
+
+	var errs []error
+	for _, feed := range d.feeds {
+		incidents, err := d.fetchIncidents(ctx, feed, deepSync)
+		if err != nil {
+			// Sources log when they become unavailable, so avoid logging on every run while they remain so
+			if errors.Is(err, source.ErrUnavailable) {
+				d.logger.Debug("Skipping unavailable source", zap.String("feed", feed.Config.Name))
+			} else {
+				d.logger.Error("Failed to fetch incidents", zap.String("feed", feed.Config.Name), zap.Error(err))
+			}
+
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, incident := range incidents {
+			d.processIncident(ctx, feed, incident)
+		}
+
+		if err := d.refreshComponents(ctx, feed, incidents); err != nil {
+			if errors.Is(err, source.ErrUnavailable) {
+				d.logger.Debug("Skipping component refresh for unavailable source", zap.String("feed", feed.Config.Name))
+			} else {
+				d.logger.Error("Failed to refresh components", zap.String("feed", feed.Config.Name), zap.Error(err))
+			}
+		}
+	}
+
+	// Retry the deep sync on the next run if any source failed to complete it
+	if deepSync && len(errs) == 0 {
+		d.lastDeepSync = time.Now()
+	}
+
+	return errors.Join(errs...)
+}
+
+// fetchIncidents returns the incidents from the feed's source to process this run. Regular runs fetch unresolved and recently
+// created incidents, plus any incident we are still tracking as unresolved that has since dropped out of that view.
+// Deep syncs fetch every incident instead to reconcile all tracked incidents.
+func (d *Daemon) fetchIncidents(ctx context.Context, feed Feed, deepSync bool) ([]model.Incident, error) {
+	incidents, err := feed.Source.ListIncidents(ctx, source.SyncRecent)
 	if err != nil {
-		d.logger.Error("Failed to fetch incidents", zap.Error(err))
-		return err
+		return nil, err
 	}
 
+	seen := make(map[string]bool, len(incidents))
 	for _, incident := range incidents {
-		exists, err := incident.Exists()
+		seen[incident.ID] = true
+	}
+
+	if deepSync {
+		all, err := feed.Source.ListIncidents(ctx, source.SyncFull)
 		if err != nil {
-			d.logger.Error("Failed to check if incident exists", zap.Error(err))
-			continue
+			return nil, err
 		}
 
-		// Order updates in reverse order
-		incident.OrderUpdates()
-		container := incident.GenerateContainer()
-		msgComponents := []component.Component{
-			component.BuildTextDisplay(component.TextDisplay{
-				Content: fmt.Sprintf("-# A new incident has been reported <@&%d>", d.config.Discord.UpdateRoleId),
-			}),
-			container,
+		trackedIds, err := d.tracked.GetIncidentIds(feed.Config.Name)
+		if err != nil {
+			return nil, err
 		}
 
-		if !exists {
-			d.logger.Info("New incident detected. Sending Discord message...", zap.String("incident_id", incident.ID), zap.String("status", incident.Status))
-			msg, err := rest.CreateMessage(ctx, d.config.Discord.Token, nil, d.config.Discord.ChannelId, rest.CreateMessageData{
-				Components: msgComponents,
-				Flags:      message.SumFlags(message.FlagComponentsV2),
-				AllowedMentions: message.AllowedMention{
-					Roles: []uint64{d.config.Discord.UpdateRoleId},
-				},
-			})
-			if err != nil {
-				d.logger.Error("Error sending message", zap.Error(err))
-				continue
+		tracked := make(map[string]bool, len(trackedIds))
+		for _, id := range trackedIds {
+			tracked[id] = true
+		}
+
+		// Only reconcile incidents we have announced, so historic incidents are never announced
+		for _, incident := range all {
+			if tracked[incident.ID] && !seen[incident.ID] {
+				seen[incident.ID] = true
+				incidents = append(incidents, incident)
 			}
+		}
 
-			channelInfo, err := rest.GetChannel(ctx, d.config.Discord.Token, nil, d.config.Discord.ChannelId)
-			if err != nil {
-				d.logger.Error("Error retrieving channel info", zap.Error(err))
-				continue
+		return incidents, nil
+	}
+
+	unresolvedIds, err := d.tracked.GetUnresolvedIncidentIds(feed.Config.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range unresolvedIds {
+		if seen[id] {
+			continue
+		}
+
+		incident, err := feed.Source.GetIncident(ctx, id)
+		if err != nil {
+			if errors.Is(err, source.ErrUnavailable) {
+				return nil, err
 			}
 
-			if channelInfo.Type == channel.ChannelTypeGuildNews && config.Conf.Discord.ShouldCrosspost {
-				if err := rest.CrosspostMessage(ctx, d.config.Discord.Token, nil, d.config.Discord.ChannelId, msg.Id); err != nil {
-					d.logger.Error("Error crossposting message", zap.Error(err))
+			// The incident has been deleted, or has aged out of what the source can return, so stop polling for it
+			if errors.Is(err, source.ErrIncidentNotFound) {
+				d.logger.Warn("Tracked incident no longer exists, no longer polling for it", zap.String("feed", feed.Config.Name), zap.String("incident_id", id))
+				if err := d.tracked.MarkIncidentMissing(feed.Config.Name, id); err != nil {
+					d.logger.Error("Failed to mark tracked incident as missing", zap.String("feed", feed.Config.Name), zap.String("incident_id", id), zap.Error(err))
 				}
+				continue
 			}
 
-			d.logger.Info("Discord message sent for incident", zap.String("incident_id", incident.ID), zap.Uint64("message_id", msg.Id))
+			d.logger.Error("Failed to fetch tracked incident", zap.String("feed", feed.Config.Name), zap.String("incident_id", id), zap.Error(err))
+			continue
+		}
 
-			// Create role & thread
-			role, err := rest.CreateGuildRole(ctx, d.config.Discord.Token, nil, d.config.Discord.GuildId, rest.GuildRoleData{
-				Name: fmt.Sprintf("Incident Updates: %s", incident.ID),
-			})
-			if err != nil {
-				d.logger.Error("Error creating role", zap.Error(err))
-				continue
-			}
+		incidents = append(incidents, incident)
+	}
 
-			thread, err := rest.StartThreadWithMessage(ctx, d.config.Discord.Token, nil, d.config.Discord.ChannelId, msg.Id, rest.StartThreadWithMessageData{
-				Name:                fmt.Sprintf("Incident Updates: %s", incident.ID),
-				AutoArchiveDuration: 1440, // 24 hours
-			})
-			if err != nil {
-				d.logger.Error("Error starting thread", zap.Error(err))
-				continue
+	return incidents, nil
+}
+
+// ProcessIncident runs a single incident for the named feed, received outside of the polling loop such as from a
+// webhook, through the same processing pipeline as the daemon.
+func (d *Daemon) ProcessIncident(ctx context.Context, feedName string, incident model.Incident) {
+	ctx, cancel := context.WithTimeout(ctx, d.config.Daemon.ExecutionTimeout)
+	defer cancel()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// The caller may have given up while a run held the lock, in which case the next run reconciles the incident
+	if err := ctx.Err(); err != nil {
+		d.logger.Warn("Skipping incident after waiting for the current run", zap.String("feed", feedName), zap.String("incident_id", incident.ID), zap.Error(err))
+		return
+	}
+
+	for _, feed := range d.feeds {
+		if feed.Config.Name == feedName {
+			d.processIncident(ctx, feed, incident)
+			return
+		}
+	}
+
+	d.logger.Warn("Received incident for unknown feed", zap.String("feed", feedName), zap.String("incident_id", incident.ID))
+}
+
+// Trigger requests an immediate run of the daemon without waiting for the next timer tick.
+func (d *Daemon) Trigger() {
+	select {
+	case d.trigger <- struct{}{}:
+	default:
+		// A run has already been requested
+	}
+}
+
+// processIncident must only be called while holding d.mu, as concurrent processing of the same incident would
+// provision it twice.
+func (d *Daemon) processIncident(ctx context.Context, feed Feed, incident model.Incident) {
+	incident.Source = feed.Config.Name
+	incident.Locale = feed.Config.Locale
+	incident.PageID = feed.pageId(incident)
+
+	if err := incident.AssignPage(); err != nil {
+		d.logger.Error("Failed to assign page to incident", zap.Error(err))
+		return
+	}
+
+	exists, err := incident.Exists()
+	if err != nil {
+		d.logger.Error("Failed to check if incident exists", zap.Error(err))
+		return
+	}
+
+	// Order updates chronologically, oldest first
+	incident.OrderUpdates()
+
+	incidentInfo := model.IncidentInfo{
+		Id:        incident.ID,
+		PageId:    incident.PageID,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Source:    incident.Source,
+	}
+	if exists {
+		incidentInfo, err = incident.Get()
+		if err != nil {
+			d.logger.Error("Error retrieving incident info", zap.Error(err))
+			return
+		}
+	}
+
+	// Incidents that had already finished when first seen, such as those listed when a feed is added, are tracked
+	// without being announced, so that they are not posted after the fact
+	if !exists && isResolved(incident.Status) {
+		incidentInfo.CurrentStatus = incident.Status
+		if err := incidentInfo.Save(); err != nil {
+			d.logger.Error("Error saving incident info", zap.Error(err))
+			return
+		}
+
+		d.logger.Debug("Tracking finished incident without announcing it", zap.String("feed", feed.Config.Name), zap.String("incident_id", incident.ID))
+		return
+	}
+
+	destinations, err := d.incidentDestinations(feed, incident, exists)
+	if err != nil {
+		d.logger.Error("Error retrieving incident destinations", zap.Error(err), zap.String("incident_id", incident.ID))
+		return
+	}
+
+	if !exists {
+		if len(destinations) == 0 {
+			d.logger.Debug("Skipping incident not matching any subscription", zap.String("feed", feed.Config.Name), zap.String("incident_id", incident.ID))
+			return
+		}
+
+		// Track the incident before anything is posted, so that it is reconciled if a destination fails part-way
+		if err := incidentInfo.Save(); err != nil {
+			d.logger.Error("Error saving incident info", zap.Error(err))
+			return
+		}
+	}
+
+	updated, complete := false, true
+	for _, dest := range destinations {
+		destUpdated, err := d.processDestination(ctx, feed, incident, incidentInfo, dest)
+		if err != nil {
+			d.logger.Error("Error processing incident destination", zap.Error(err),
+				zap.String("incident_id", incident.ID),
+				zap.Uint64("guild_id", dest.GuildId),
+				zap.Uint64("channel_id", dest.ChannelId),
+				zap.String("state", string(dest.State)),
+			)
+			complete = false
+			continue
+		}
+
+		updated = updated || destUpdated
+	}
+
+	// Resolved incidents are still fetched on every run while they are recent, so skip them once their resolution has
+	// been recorded
+	if !exists || !isResolved(incidentInfo.CurrentStatus) {
+		d.queueDirectMessages(feed, incident)
+	}
+
+	// Keep the previous status until every destination is up to date, so the incident is still polled as unresolved
+	if !complete || (!updated && incidentInfo.CurrentStatus == incident.Status) {
+		return
+	}
+
+	incidentInfo.CurrentStatus = incident.Status
+	incidentInfo.UpdatedAt = time.Now()
+
+	if err := incidentInfo.Save(); err != nil {
+		d.logger.Error("Error saving incident info", zap.Error(err))
+		return
+	}
+}
+
+// destination is a channel an incident is announced in, along with the subscription that routed it there.
+type destination struct {
+	model.IncidentDestination
+	Subscription model.Subscription
+}
+
+// subscriptions returns every subscription to the feed: the feed's own channel in the configured guild, followed by
+// those stored in the database.
+func (d *Daemon) subscriptions(feed Feed) ([]model.Subscription, error) {
+	stored, err := model.GetSubscriptions(feed.Config.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]model.Subscription{d.homeSubscription(feed)}, stored...), nil
+}
+
+// homeSubscription returns the subscription of the feed's own channel in the configured guild.
+func (d *Daemon) homeSubscription(feed Feed) model.Subscription {
+	return model.Subscription{
+		Feed:            feed.Config.Name,
+		GuildId:         d.config.Discord.GuildId,
+		ChannelId:       feed.Config.ChannelId,
+		RoleId:          feed.Config.UpdateRoleId,
+		Components:      feed.Config.Components,
+		ShouldCrosspost: feed.Config.ShouldCrosspost,
+	}
+}
+
+// incidentDestinations returns every channel the incident has been announced in, along with any subscribed channel
+// it should now be announced in. New destinations are only added while an incident is unresolved or yet to be
+// tracked, so that subscribing never announces past incidents.
+func (d *Daemon) incidentDestinations(feed Feed, incident model.Incident, exists bool) ([]destination, error) {
+	subscriptions, err := d.subscriptions(feed)
+	if err != nil {
+		return nil, err
+	}
+
+	byChannel := make(map[uint64]model.Subscription, len(subscriptions))
+	for _, sub := range subscriptions {
+		byChannel[sub.ChannelId] = sub
+	}
+
+	var existing []model.IncidentDestination
+	if exists {
+		existing, err = model.GetIncidentDestinations(feed.Config.Name, incident.PageID, incident.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	destinations := make([]destination, 0, len(subscriptions))
+	announced := make(map[uint64]bool, len(existing))
+	for _, dest := range existing {
+		announced[dest.ChannelId] = true
+
+		// The subscription may have since been removed, in which case the incident continues to be updated, but
+		// without mentioning its role
+		sub, ok := byChannel[dest.ChannelId]
+		if !ok {
+			sub = model.Subscription{Feed: feed.Config.Name, GuildId: dest.GuildId, ChannelId: dest.ChannelId}
+		}
+
+		destinations = append(destinations, destination{IncidentDestination: dest, Subscription: sub})
+	}
+
+	if exists && isResolved(incident.Status) {
+		return destinations, nil
+	}
+
+	componentIds := incident.ComponentIds()
+	for _, sub := range subscriptions {
+		if announced[sub.ChannelId] || !sub.MatchesComponents(componentIds) {
+			continue
+		}
+
+		destinations = append(destinations, destination{
+			IncidentDestination: model.IncidentDestination{
+				Feed:       feed.Config.Name,
+				PageId:     incident.PageID,
+				IncidentId: incident.ID,
+				GuildId:    sub.GuildId,
+				ChannelId:  sub.ChannelId,
+				CreatedAt:  time.Now(),
+			},
+			Subscription: sub,
+		})
+	}
+
+	return destinations, nil
+}
+
+// processDestination brings the announcement of an incident in a single channel up to date, returning whether any
+// new updates were delivered.
+func (d *Daemon) processDestination(ctx context.Context, feed Feed, incident model.Incident, incidentInfo model.IncidentInfo, dest destination) (bool, error) {
+	if dest.Subscription.Locale != "" {
+		incident.Locale = dest.Subscription.Locale
+	}
+
+	event := model.EventIncident
+	if incident.IsMaintenance() {
+		event = model.EventMaintenance
+	}
+
+	msgComponents := []component.Component{
+		component.BuildTextDisplay(component.TextDisplay{
+			Content: incident.MentionLine(event, dest.Subscription.RoleId),
+		}),
+		incident.GenerateContainer(),
+	}
+
+	// Provision the Discord resources for new destinations, or resume where a previous run left off
+	if dest.State != model.IncidentStateReady {
+		if err := d.provisionDestination(ctx, feed, incident, &dest, msgComponents); err != nil {
+			return false, fmt.Errorf("error provisioning incident: %w", err)
+		}
+	}
+
+	pending, err := d.pendingUpdates(incident, incidentInfo, dest.IncidentDestination)
+	if err != nil {
+		return false, fmt.Errorf("error retrieving delivered updates: %w", err)
+	}
+
+	var notices []maintenanceNotice
+	if incident.IsMaintenance() {
+		sent, err := d.notices.SentNotices(dest.IncidentDestination)
+		if err != nil {
+			return false, fmt.Errorf("error retrieving sent notices: %w", err)
+		}
+
+		var skipped []string
+		notices, skipped = dueMaintenanceNotices(incident, dest.IncidentDestination, sent, time.Now())
+		for _, key := range skipped {
+			if err := d.notices.MarkNoticeSent(dest.IncidentDestination, key); err != nil {
+				return false, fmt.Errorf("error marking notice %s as sent: %w", key, err)
 			}
+		}
+	}
+
+	updated := len(pending) > 0 || len(notices) > 0
+	if updated {
+		if err := d.deliverUpdates(ctx, incident, dest, msgComponents, pending, notices); err != nil {
+			return true, err
+		}
+	}
+
+	// The resolution is retried on every run until it succeeds, even once every update has been delivered. Incidents are
+	// only recorded as resolved once every destination has been resolved, after which there is nothing left to do.
+	if isResolved(incident.Status) && !isResolved(incidentInfo.CurrentStatus) {
+		if err := d.resolveDestination(ctx, feed, incident, dest); err != nil {
+			return updated, err
+		}
+	}
+
+	return updated, d.deliverPostmortem(ctx, feed, incident, dest)
+}
 
-			incidentInfo := model.IncidentInfo{
-				Id:            incident.ID,
-				RoleId:        role.Id,
-				MessageId:     msg.Id,
-				ThreadId:      thread.Id,
-				CreatedAt:     time.Now(),
-				UpdatedAt:     time.Now(),
-				CurrentStatus: incident.Status,
+// deliverUpdates edits the announcement to reflect the incident's current state, then posts the pending updates and
+// due notices to the incident thread.
+func (d *Daemon) deliverUpdates(ctx context.Context, incident model.Incident, dest destination, msgComponents []component.Component, pending []model.IncidentUpdate, notices []maintenanceNotice) error {
+	d.logger.Info("Update detected for incident. Editing Discord message...",
+		zap.String("incident_id", incident.ID),
+		zap.Uint64("channel_id", dest.ChannelId),
+		zap.Uint64("message_id", dest.MessageId),
+		zap.Int("pending_updates", len(pending)),
+	)
+	// Update the message to reflect the new updates
+	_, err := d.discord.EditMessage(ctx, dest.ChannelId, dest.MessageId, rest.EditMessageData{
+		Components: msgComponents,
+		Flags:      message.SumFlags(message.FlagComponentsV2),
+	})
+	if err != nil {
+		return fmt.Errorf("error editing message: %w", err)
+	}
+
+	d.logger.Info("Discord message updated for incident", zap.String("incident_id", incident.ID), zap.Uint64("channel_id", dest.ChannelId))
+
+	published, err := dest.PublishedUpdates()
+	if err != nil {
+		return fmt.Errorf("error retrieving published updates: %w", err)
+	}
+
+	// Send every undelivered update to the thread, oldest first, stopping at the first failure so
+	// that the thread never shows updates out of order
+	for _, update := range pending {
+		// Updates published from the thread already appear there as the message they were published from
+		if published[update.ID] {
+			if err := dest.MarkUpdateDelivered(update.ID); err != nil {
+				return fmt.Errorf("error marking update %s as delivered: %w", update.ID, err)
 			}
+			continue
+		}
 
-			if err := incidentInfo.Save(); err != nil {
-				d.logger.Error("Error saving incident info", zap.Error(err))
-				continue
+		// Long updates are split across several messages, with only the first mentioning the incident role
+		for idx, updateContainer := range incident.GenerateUpdateContainers(update) {
+			components := []component.Component{updateContainer}
+			if idx == 0 {
+				components = append([]component.Component{component.BuildTextDisplay(component.TextDisplay{
+					Content: incident.MentionLine(model.EventUpdate, dest.RoleId),
+				})}, components...)
 			}
-			d.logger.Info("Incident info saved", zap.String("incident_id", incident.ID), zap.Uint64("role_id", role.Id), zap.Uint64("message_id", msg.Id), zap.Uint64("thread_id", thread.Id))
 
-		} else {
-			incidentInfo, err := incident.Get()
+			_, err = d.discord.CreateMessage(ctx, dest.ThreadId, rest.CreateMessageData{
+				Components: components,
+				Flags:      message.SumFlags(message.FlagComponentsV2),
+				AllowedMentions: message.AllowedMention{
+					Roles: []uint64{dest.RoleId},
+				},
+			})
 			if err != nil {
-				d.logger.Error("Error retrieving incident info", zap.Error(err))
-				continue
+				return fmt.Errorf("error creating message in thread for update %s: %w", update.ID, err)
 			}
-			if incident.IncidentUpdates[len(incident.IncidentUpdates)-1].DisplayAt.After(incidentInfo.UpdatedAt) {
-				d.logger.Info("Update detected for incident. Editing Discord message...",
-					zap.String("incident_id", incident.ID),
-					zap.Uint64("message_id", incidentInfo.MessageId),
-				)
-				// Update the message if the last update is newer
-				_, err := rest.EditMessage(ctx, d.config.Discord.Token, nil, d.config.Discord.ChannelId, incidentInfo.MessageId, rest.EditMessageData{
-					Components: msgComponents,
-					Flags:      message.SumFlags(message.FlagComponentsV2),
-				})
-				if err != nil {
-					d.logger.Error("Error editing message", zap.Error(err))
-					continue
-				}
+		}
 
-				d.logger.Info("Discord message updated for incident", zap.String("incident_id", incident.ID))
-
-				// Send update to thread
-				mostRecentUpdate := incident.IncidentUpdates[len(incident.IncidentUpdates)-1]
-				updateContainer := incident.GenerateUpdateContainer(mostRecentUpdate)
-				_, err = rest.CreateMessage(ctx, d.config.Discord.Token, nil, incidentInfo.ThreadId, rest.CreateMessageData{
-					Components: []component.Component{
-						component.BuildTextDisplay(component.TextDisplay{
-							Content: fmt.Sprintf("-# A new update has been posted <@&%d>", incidentInfo.RoleId),
-						}),
-						updateContainer,
-					},
-					Flags: message.SumFlags(message.FlagComponentsV2),
-					AllowedMentions: message.AllowedMention{
-						Roles: []uint64{incidentInfo.RoleId},
-					},
-				})
-				if err != nil {
-					d.logger.Error("Error creating message in thread", zap.Error(err))
-					continue
-				}
+		if err := dest.MarkUpdateDelivered(update.ID); err != nil {
+			return fmt.Errorf("error marking update %s as delivered: %w", update.ID, err)
+		}
 
-				d.logger.Info("Update message sent in thread", zap.String("incident_id", incident.ID), zap.Uint64("thread_id", incidentInfo.ThreadId))
-
-				// Check if its resolved, if it is, close everything down
-				if incident.Status == "resolved" || incident.Status == "completed" {
-					d.logger.Info("Incident resolved, closing thread and removing role", zap.String("incident_id", incident.ID))
-					archive := true
-
-					// Close the thread
-					if _, err := rest.ModifyChannel(ctx, d.config.Discord.Token, nil, incidentInfo.ThreadId, rest.ModifyChannelData{
-						ThreadMetadataModifyData: &rest.ThreadMetadataModifyData{
-							Archived: &archive,
-							Locked:   &archive,
-						},
-					}); err != nil {
-						d.logger.Error("Error closing thread", zap.Error(err))
-					}
-
-					// Delete the role
-					if err := rest.DeleteGuildRole(ctx, d.config.Discord.Token, nil, d.config.Discord.GuildId, incidentInfo.RoleId); err != nil {
-						d.logger.Error("Error deleting role", zap.Error(err))
-					}
-
-					d.logger.Info("Thread closed and role deleted for incident", zap.String("incident_id", incident.ID))
-				}
+		d.logger.Info("Update message sent in thread", zap.String("incident_id", incident.ID), zap.String("update_id", update.ID), zap.Uint64("thread_id", dest.ThreadId))
+	}
 
-				incidentInfo.CurrentStatus = incident.Status
-				incidentInfo.UpdatedAt = time.Now()
+	// Notices follow the updates, which usually describe the transition that triggered them
+	return d.postMaintenanceNotices(ctx, incident, dest, notices)
+}
 
-				if err := incidentInfo.Save(); err != nil {
-					d.logger.Error("Error saving incident info", zap.Error(err))
-					continue
-				}
+// resolveDestination closes down the destination of a resolved incident: the resolution message is posted, the thread
+// closed and the incident role deleted. Once every step has succeeded, the resolution notice is recorded so that it is
+// not repeated.
+func (d *Daemon) resolveDestination(ctx context.Context, feed Feed, incident model.Incident, dest destination) error {
+	sent, err := d.notices.SentNotices(dest.IncidentDestination)
+	if err != nil {
+		return fmt.Errorf("error retrieving sent notices: %w", err)
+	}
+
+	if sent[noticeResolution] {
+		return nil
+	}
+
+	d.logger.Info("Incident resolved, closing thread and removing role", zap.String("incident_id", incident.ID), zap.Uint64("channel_id", dest.ChannelId))
+
+	// The maintenance completed notice is posted in place of the resolution message
+	if !sent[noticeResolutionMessage] && !sent[noticeMaintenanceCompleted] {
+		if err := d.postResolution(ctx, incident, dest); err != nil {
+			return err
+		}
+	}
+
+	if !feed.Config.KeepThreadsOpen {
+		if err := d.setThreadClosed(ctx, dest.ThreadId, true); err != nil && !isNotFound(err) {
+			return fmt.Errorf("error closing thread: %w", err)
+		}
+	}
+
+	if dest.RoleId != 0 {
+		if err := d.discord.DeleteGuildRole(ctx, dest.GuildId, dest.RoleId); err != nil && !isNotFound(err) {
+			return fmt.Errorf("error deleting role: %w", err)
+		}
+	}
+
+	if err := d.notices.MarkNoticeSent(dest.IncidentDestination, noticeResolution); err != nil {
+		return fmt.Errorf("error marking resolution as sent: %w", err)
+	}
+
+	d.logger.Info("Thread closed and role deleted for incident", zap.String("incident_id", incident.ID), zap.Uint64("channel_id", dest.ChannelId))
+	return nil
+}
+
+// pendingUpdates returns the incident updates that have not yet been posted to the destination's thread, oldest
+// first. Incidents tracked before delivery tracking existed have no recorded updates, so every update displayed
+// before the incident was last updated is treated as delivered and recorded as such.
+func (d *Daemon) pendingUpdates(incident model.Incident, incidentInfo model.IncidentInfo, dest model.IncidentDestination) ([]model.IncidentUpdate, error) {
+	delivered, err := dest.DeliveredUpdates()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(delivered) == 0 {
+		for _, update := range incident.IncidentUpdates {
+			if update.DisplayAt.After(incidentInfo.UpdatedAt) {
+				continue
+			}
+
+			if err := dest.MarkUpdateDelivered(update.ID); err != nil {
+				return nil, err
 			}
+			delivered[update.ID] = true
 		}
+	}
+
+	var pending []model.IncidentUpdate
+	for _, update := range incident.IncidentUpdates {
+		if !delivered[update.ID] {
+			pending = append(pending, update)
+		}
+	}
+
+	return pending, nil
+}
+
+// isResolved reports whether an incident with the given status has finished.
+func isResolved(status string) bool {
+	return model.IsResolvedStatus(status)
+}
 
-		continue
+// postResolution posts the resolution message to the incident thread.
+func (d *Daemon) postResolution(ctx context.Context, incident model.Incident, dest destination) error {
+	if _, err := d.discord.CreateMessage(ctx, dest.ThreadId, rest.CreateMessageData{
+		Components: []component.Component{incident.GenerateResolutionContainer()},
+		Flags:      message.SumFlags(message.FlagComponentsV2),
+	}); err != nil {
+		return fmt.Errorf("error creating resolution message in thread: %w", err)
+	}
+
+	if err := d.notices.MarkNoticeSent(dest.IncidentDestination, noticeResolutionMessage); err != nil {
+		return fmt.Errorf("error marking resolution message as sent: %w", err)
 	}
 
 	return nil
 }
+
+// roleMention returns a mention of the given role, prefixed with a space, or an empty string if no role is set.
+func roleMention(roleId uint64) string {
+	if roleId == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" <@&%d>", roleId)
+}
+
+// isNotFound reports whether the Discord API responded to the request with 404 Not Found.
+func isNotFound(err error) bool {
+	var restErr request.RestError
+	return errors.As(err, &restErr) && restErr.StatusCode == 404
+}
@@ -0,0 +1,306 @@
+package daemon
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/TicketsBot-cloud/gdl/objects/channel"
+	"github.com/TicketsBot-cloud/gdl/objects/channel/message"
+	"github.com/TicketsBot-cloud/gdl/objects/guild"
+	"github.com/TicketsBot-cloud/gdl/rest"
+	"github.com/TicketsBot-cloud/status-updates/internal/config"
+	"github.com/TicketsBot-cloud/status-updates/internal/model"
+	"github.com/TicketsBot-cloud/status-updates/internal/source"
+	"go.uber.org/zap"
+)
+
+// recentSource only lists unresolved incidents when asked for recent incidents, so resolved incidents drop out of the
+// recent view as they would on a busy status page.
+type recentSource struct {
+	*source.Fake
+}
+
+func (s recentSource) ListIncidents(ctx context.Context, mode source.SyncMode) ([]model.Incident, error) {
+	incidents, err := s.Fake.ListIncidents(ctx, mode)
+	if err != nil || mode == source.SyncFull {
+		return incidents, err
+	}
+
+	return slices.DeleteFunc(incidents, func(incident model.Incident) bool {
+		return isResolved(incident.Status)
+	}), nil
+}
+
+// fakeTrackedIncidents maps the IDs of tracked incidents to their recorded status.
+type fakeTrackedIncidents map[string]string
+
+func (f fakeTrackedIncidents) GetIncidentIds(_ string) ([]string, error) {
+	var ids []string
+	for id := range f {
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (f fakeTrackedIncidents) GetUnresolvedIncidentIds(_ string) ([]string, error) {
+	var ids []string
+	for id, status := range f {
+		if !isResolved(status) && status != model.IncidentStatusMissing {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+func (f fakeTrackedIncidents) MarkIncidentMissing(_, incidentId string) error {
+	f[incidentId] = model.IncidentStatusMissing
+	return nil
+}
+
+func TestFetchIncidents(t *testing.T) {
+	fake := source.NewFake("fake")
+	fake.SetIncident(model.Incident{ID: "active", Status: "investigating"})
+	fake.SetIncident(model.Incident{ID: "resolved-tracked", Status: "resolved"})
+	fake.SetIncident(model.Incident{ID: "resolved-announced", Status: "resolved"})
+	fake.SetIncident(model.Incident{ID: "historic", Status: "resolved"})
+
+	feed := Feed{
+		Config: config.Feed{Name: "fake"},
+		Source: recentSource{fake},
+	}
+
+	d := &Daemon{
+		logger: zap.NewNop(),
+		feeds:  []Feed{feed},
+		tracked: fakeTrackedIncidents{
+			"active":             "investigating",
+			"resolved-tracked":   "investigating", // resolved on the page since the last run
+			"resolved-announced": "resolved",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		deepSync bool
+		want     []string
+	}{
+		{
+			name: "recent",
+			want: []string{"active", "resolved-tracked"},
+		},
+		{
+			name:     "deep sync",
+			deepSync: true,
+			want:     []string{"active", "resolved-announced", "resolved-tracked"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			incidents, err := d.fetchIncidents(context.Background(), feed, test.deepSync)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var ids []string
+			for _, incident := range incidents {
+				ids = append(ids, incident.ID)
+			}
+			slices.Sort(ids)
+
+			if !slices.Equal(ids, test.want) {
+				t.Errorf("got incidents %v, want %v", ids, test.want)
+			}
+		})
+	}
+}
+
+func TestFetchIncidentsMissing(t *testing.T) {
+	fake := source.NewFake("fake")
+	fake.SetIncident(model.Incident{ID: "active", Status: "investigating"})
+
+	feed := Feed{
+		Config: config.Feed{Name: "fake"},
+		Source: fake,
+	}
+
+	tracked := fakeTrackedIncidents{
+		"active":  "investigating",
+		"deleted": "investigating", // deleted from the page since it was announced
+	}
+
+	d := &Daemon{
+		logger:  zap.NewNop(),
+		feeds:   []Feed{feed},
+		tracked: tracked,
+	}
+
+	incidents, err := d.fetchIncidents(context.Background(), feed, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(incidents) != 1 || incidents[0].ID != "active" {
+		t.Errorf("got incidents %v, want only the active incident", incidents)
+	}
+
+	if status := tracked["deleted"]; status != model.IncidentStatusMissing {
+		t.Errorf("deleted incident has status %q, want %q", status, model.IncidentStatusMissing)
+	}
+}
+
+// fakeDiscord records the messages created in each channel, and accepts every other call.
+type fakeDiscord struct {
+	messages map[uint64][]rest.CreateMessageData
+}
+
+func (f *fakeDiscord) CreateMessage(_ context.Context, channelId uint64, data rest.CreateMessageData) (message.Message, error) {
+	if f.messages == nil {
+		f.messages = make(map[uint64][]rest.CreateMessageData)
+	}
+
+	f.messages[channelId] = append(f.messages[channelId], data)
+	return message.Message{ChannelId: channelId}, nil
+}
+
+func (f *fakeDiscord) EditMessage(_ context.Context, channelId, messageId uint64, _ rest.EditMessageData) (message.Message, error) {
+	return message.Message{Id: messageId, ChannelId: channelId}, nil
+}
+
+func (f *fakeDiscord) CrosspostMessage(context.Context, uint64, uint64) error {
+	return nil
+}
+
+func (f *fakeDiscord) AddPinnedChannelMessage(context.Context, uint64, uint64) error {
+	return nil
+}
+
+func (f *fakeDiscord) GetChannel(_ context.Context, channelId uint64) (channel.Channel, error) {
+	return channel.Channel{Id: channelId}, nil
+}
+
+func (f *fakeDiscord) ModifyChannel(_ context.Context, channelId uint64, _ rest.ModifyChannelData) (channel.Channel, error) {
+	return channel.Channel{Id: channelId}, nil
+}
+
+func (f *fakeDiscord) StartThreadWithMessage(context.Context, uint64, uint64, rest.StartThreadWithMessageData) (channel.Channel, error) {
+	return channel.Channel{}, nil
+}
+
+func (f *fakeDiscord) AddThreadMember(context.Context, uint64, uint64) error {
+	return nil
+}
+
+func (f *fakeDiscord) CreateGuildRole(context.Context, uint64, rest.GuildRoleData) (guild.Role, error) {
+	return guild.Role{}, nil
+}
+
+func (f *fakeDiscord) DeleteGuildRole(context.Context, uint64, uint64) error {
+	return nil
+}
+
+func (f *fakeDiscord) AddGuildMemberRole(context.Context, uint64, uint64, uint64) error {
+	return nil
+}
+
+func (f *fakeDiscord) CreateDM(_ context.Context, recipientId uint64) (channel.Channel, error) {
+	return channel.Channel{Id: recipientId}, nil
+}
+
+// fakeSentNotices holds the notices sent to each channel.
+type fakeSentNotices map[uint64]map[string]bool
+
+func (f fakeSentNotices) SentNotices(dest model.IncidentDestination) (map[string]bool, error) {
+	sent := make(map[string]bool, len(f[dest.ChannelId]))
+	for notice := range f[dest.ChannelId] {
+		sent[notice] = true
+	}
+
+	return sent, nil
+}
+
+func (f fakeSentNotices) MarkNoticeSent(dest model.IncidentDestination, notice string) error {
+	if f[dest.ChannelId] == nil {
+		f[dest.ChannelId] = make(map[string]bool)
+	}
+
+	f[dest.ChannelId][notice] = true
+	return nil
+}
+
+func TestDeliverPostmortem(t *testing.T) {
+	const threadId = 4
+
+	fake := source.NewFake("fake")
+	feed := Feed{
+		Config: config.Feed{Name: "fake"},
+		Source: fake,
+	}
+
+	discord := &fakeDiscord{}
+	notices := fakeSentNotices{}
+	d := &Daemon{
+		logger:  zap.NewNop(),
+		feeds:   []Feed{feed},
+		notices: notices,
+		discord: discord,
+	}
+
+	dest := destination{
+		IncidentDestination: model.IncidentDestination{
+			Feed:       "fake",
+			IncidentId: "incident",
+			GuildId:    1,
+			ChannelId:  2,
+			MessageId:  3,
+			ThreadId:   threadId,
+			State:      model.IncidentStateReady,
+		},
+	}
+
+	deliver := func() {
+		t.Helper()
+
+		incident, err := fake.GetIncident(context.Background(), "incident")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := d.deliverPostmortem(context.Background(), feed, incident, dest); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	incident := model.Incident{ID: "incident", Name: "Outage", Status: "resolved"}
+	fake.SetIncident(incident)
+	deliver()
+
+	if posted := len(discord.messages[threadId]); posted != 0 {
+		t.Fatalf("posted %d messages before the postmortem was published", posted)
+	}
+
+	// Statuspage moves the incident from resolved to postmortem once the postmortem is published
+	incident.Status = "postmortem"
+	incident.PostmortemBody = "The database ran out of disk space."
+	incident.PostmortemPublishedAt = time.Now()
+	fake.SetIncident(incident)
+	deliver()
+
+	posted := len(discord.messages[threadId])
+	if posted == 0 {
+		t.Fatal("postmortem was not posted to the incident thread")
+	}
+
+	if !notices[dest.ChannelId][noticePostmortem] {
+		t.Error("postmortem was not recorded as sent")
+	}
+
+	deliver()
+	if len(discord.messages[threadId]) != posted {
+		t.Error("postmortem was posted again")
+	}
+}
@@ -0,0 +1,81 @@
+package daemon
+
+import (
+	"context"
+
+	"github.com/TicketsBot-cloud/gdl/objects/channel"
+	"github.com/TicketsBot-cloud/gdl/objects/channel/message"
+	"github.com/TicketsBot-cloud/gdl/objects/guild"
+	"github.com/TicketsBot-cloud/gdl/rest"
+)
+
+// discordClient is the subset of the Discord API used by the daemon.
+type discordClient interface {
+	CreateMessage(ctx context.Context, channelId uint64, data rest.CreateMessageData) (message.Message, error)
+	EditMessage(ctx context.Context, channelId, messageId uint64, data rest.EditMessageData) (message.Message, error)
+	CrosspostMessage(ctx context.Context, channelId, messageId uint64) error
+	AddPinnedChannelMessage(ctx context.Context, channelId, messageId uint64) error
+	GetChannel(ctx context.Context, channelId uint64) (channel.Channel, error)
+	ModifyChannel(ctx context.Context, channelId uint64, data rest.ModifyChannelData) (channel.Channel, error)
+	StartThreadWithMessage(ctx context.Context, channelId, messageId uint64, data rest.StartThreadWithMessageData) (channel.Channel, error)
+	AddThreadMember(ctx context.Context, channelId, userId uint64) error
+	CreateGuildRole(ctx context.Context, guildId uint64, data rest.GuildRoleData) (guild.Role, error)
+	DeleteGuildRole(ctx context.Context, guildId, roleId uint64) error
+	AddGuildMemberRole(ctx context.Context, guildId, userId, roleId uint64) error
+	CreateDM(ctx context.Context, recipientId uint64) (channel.Channel, error)
+}
+
+// restClient calls the Discord API as the bot with the given token.
+type restClient struct {
+	token string
+}
+
+var _ discordClient = restClient{}
+
+func (c restClient) CreateMessage(ctx context.Context, channelId uint64, data rest.CreateMessageData) (message.Message, error) {
+	return rest.CreateMessage(ctx, c.token, nil, channelId, data)
+}
+
+func (c restClient) EditMessage(ctx context.Context, channelId, messageId uint64, data rest.EditMessageData) (message.Message, error) {
+	return rest.EditMessage(ctx, c.token, nil, channelId, messageId, data)
+}
+
+func (c restClient) CrosspostMessage(ctx context.Context, channelId, messageId uint64) error {
+	return rest.CrosspostMessage(ctx, c.token, nil, channelId, messageId)
+}
+
+func (c restClient) AddPinnedChannelMessage(ctx context.Context, channelId, messageId uint64) error {
+	return rest.AddPinnedChannelMessage(ctx, c.token, nil, channelId, messageId)
+}
+
+func (c restClient) GetChannel(ctx context.Context, channelId uint64) (channel.Channel, error) {
+	return rest.GetChannel(ctx, c.token, nil, channelId)
+}
+
+func (c restClient) ModifyChannel(ctx context.Context, channelId uint64, data rest.ModifyChannelData) (channel.Channel, error) {
+	return rest.ModifyChannel(ctx, c.token, nil, channelId, data)
+}
+
+func (c restClient) StartThreadWithMessage(ctx context.Context, channelId, messageId uint64, data rest.StartThreadWithMessageData) (channel.Channel, error) {
+	return rest.StartThreadWithMessage(ctx, c.token, nil, channelId, messageId, data)
+}
+
+func (c restClient) AddThreadMember(ctx context.Context, channelId, userId uint64) error {
+	return rest.AddThreadMember(ctx, c.token, nil, channelId, userId)
+}
+
+func (c restClient) CreateGuildRole(ctx context.Context, guildId uint64, data rest.GuildRoleData) (guild.Role, error) {
+	return rest.CreateGuildRole(ctx, c.token, nil, guildId, data)
+}
+
+func (c restClient) DeleteGuildRole(ctx context.Context, guildId, roleId uint64) error {
+	return rest.DeleteGuildRole(ctx, c.token, nil, guildId, roleId)
+}
+
+func (c restClient) AddGuildMemberRole(ctx context.Context, guildId, userId, roleId uint64) error {
+	return rest.AddGuildMemberRole(ctx, c.token, nil, guildId, userId, roleId)
+}
+
+func (c restClient) CreateDM(ctx context.Context, recipientId uint64) (channel.Channel, error) {
+	return rest.CreateDM(ctx, c.token, nil, recipientId)
+}
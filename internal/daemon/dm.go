@@ -0,0 +1,244 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/TicketsBot-cloud/gdl/objects/channel/message"
+	"github.com/TicketsBot-cloud/gdl/objects/interaction/component"
+	"github.com/TicketsBot-cloud/gdl/rest"
+	"github.com/TicketsBot-cloud/gdl/rest/request"
+	"github.com/TicketsBot-cloud/status-updates/internal/model"
+	"go.uber.org/zap"
+)
+
+// errDmRateLimited is returned when a user has been sent too many direct messages recently
+var errDmRateLimited = errors.New("direct message rate limit exceeded")
+
+// dmLimiter limits how many direct messages are sent to each user within a sliding window.
+type dmLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	sent   map[uint64][]time.Time
+}
+
+func newDmLimiter(limit int, window time.Duration) *dmLimiter {
+	return &dmLimiter{
+		limit:  limit,
+		window: window,
+		sent:   make(map[uint64][]time.Time),
+	}
+}
+
+// allow reports whether another direct message may be sent to the user, and if so, records it as sent.
+func (l *dmLimiter) allow(userId uint64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := time.Now().Add(-l.window)
+	recent := l.sent[userId][:0]
+	for _, at := range l.sent[userId] {
+		if at.After(cutoff) {
+			recent = append(recent, at)
+		}
+	}
+
+	if len(recent) >= l.limit {
+		l.sent[userId] = recent
+		return false
+	}
+
+	l.sent[userId] = append(recent, time.Now())
+	return true
+}
+
+// dmQueueSize is how many incidents may be waiting for their direct messages to be sent. Incidents are queued again on
+// every run until they are resolved, so any that do not fit are sent on a later run.
+const dmQueueSize = 100
+
+// dmJob is an incident whose updates are waiting to be sent to its followers.
+type dmJob struct {
+	feed     Feed
+	incident model.Incident
+}
+
+// queueDirectMessages queues the updates of the incident to be sent to its followers. Direct messages are sent by a
+// single worker outside of d.mu, so that many followers or a slow Discord API do not hold up processing.
+func (d *Daemon) queueDirectMessages(feed Feed, incident model.Incident) {
+	select {
+	case d.dmJobs <- dmJob{feed: feed, incident: incident}:
+	default:
+		d.logger.Warn("Direct message queue is full, deferring to a later run", zap.String("feed", feed.Config.Name), zap.String("incident_id", incident.ID))
+	}
+}
+
+// runDirectMessages sends the direct messages of each queued incident in turn, until ctx is cancelled.
+func (d *Daemon) runDirectMessages(ctx context.Context) {
+	for {
+		select {
+		case job := <-d.dmJobs:
+			jobCtx, cancel := context.WithTimeout(ctx, d.config.Daemon.ExecutionTimeout)
+			d.deliverDirectMessages(jobCtx, job.feed, job.incident)
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// deliverDirectMessages sends each update of the incident to the users who follow it, either by opting in with the
+// announcement's button or by following an affected component, and who have enabled direct messages. Users are only
+// sent updates posted after they started following the incident and enabled direct messages.
+func (d *Daemon) deliverDirectMessages(ctx context.Context, feed Feed, incident model.Incident) {
+	followers, err := model.GetIncidentFollowers(incident.ID)
+	if err != nil {
+		d.logger.Error("Error retrieving incident followers", zap.Error(err), zap.String("incident_id", incident.ID))
+		return
+	}
+
+	if componentIds := incident.ComponentIds(); len(componentIds) > 0 {
+		componentFollowers, err := model.GetComponentFollowers(feed.Config.Name, componentIds)
+		if err != nil {
+			d.logger.Error("Error retrieving component followers", zap.Error(err), zap.String("incident_id", incident.ID))
+			return
+		}
+
+		// Component followers receive every update of the incident
+		for userId := range componentFollowers {
+			followers[userId] = time.Time{}
+		}
+	}
+
+	if len(followers) == 0 {
+		return
+	}
+
+	userIds := make([]uint64, 0, len(followers))
+	for userId := range followers {
+		userIds = append(userIds, userId)
+	}
+
+	recipients, err := model.GetEnabledDmPreferences(userIds)
+	if err != nil {
+		d.logger.Error("Error retrieving DM preferences", zap.Error(err), zap.String("incident_id", incident.ID))
+		return
+	}
+
+	if len(recipients) == 0 {
+		return
+	}
+
+	recipientIds := make([]uint64, 0, len(recipients))
+	for _, prefs := range recipients {
+		recipientIds = append(recipientIds, prefs.UserId)
+	}
+
+	delivered, err := model.GetDeliveredDmUpdates(incident.ID, recipientIds)
+	if err != nil {
+		d.logger.Error("Error retrieving delivered direct messages", zap.Error(err), zap.String("incident_id", incident.ID))
+		return
+	}
+
+	for _, prefs := range recipients {
+		since := prefs.EnabledAt
+		if followers[prefs.UserId].After(since) {
+			since = followers[prefs.UserId]
+		}
+
+		if err := d.deliverDirectMessagesTo(ctx, prefs, incident, delivered[prefs.UserId], since); err != nil {
+			if errors.Is(err, errDmRateLimited) {
+				d.logger.Debug("Deferring direct messages for rate limited user", zap.Uint64("user_id", prefs.UserId))
+				continue
+			}
+
+			d.logger.Warn("Error sending direct message", zap.Error(err), zap.Uint64("user_id", prefs.UserId), zap.String("incident_id", incident.ID))
+		}
+	}
+}
+
+// deliverDirectMessagesTo sends the user each update of the incident posted since the given time that is not in
+// delivered.
+func (d *Daemon) deliverDirectMessagesTo(ctx context.Context, prefs model.DmPreferences, incident model.Incident, delivered map[string]bool, since time.Time) error {
+	if prefs.Locale != "" {
+		incident.Locale = prefs.Locale
+	}
+
+	for _, update := range incident.IncidentUpdates {
+		if delivered[update.ID] || update.DisplayAt.Before(since) {
+			continue
+		}
+
+		if !d.dmLimiter.allow(prefs.UserId) {
+			return errDmRateLimited
+		}
+
+		if err := d.sendDirectMessage(ctx, &prefs, incident, update); err != nil {
+			return d.recordDmFailure(prefs, err)
+		}
+
+		if err := prefs.MarkUpdateDelivered(incident.ID, update.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Daemon) sendDirectMessage(ctx context.Context, prefs *model.DmPreferences, incident model.Incident, update model.IncidentUpdate) error {
+	opened := false
+	if prefs.ChannelId == 0 {
+		dmChannel, err := d.discord.CreateDM(ctx, prefs.UserId)
+		if err != nil {
+			return fmt.Errorf("error opening DM channel: %w", err)
+		}
+
+		prefs.ChannelId = dmChannel.Id
+		opened = true
+	}
+
+	for _, updateContainer := range incident.GenerateUpdateContainers(update) {
+		if _, err := d.discord.CreateMessage(ctx, prefs.ChannelId, rest.CreateMessageData{
+			Components: []component.Component{updateContainer},
+			Flags:      message.SumFlags(message.FlagComponentsV2),
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Reset the failure count now that the user can be reached, and persist the opened channel
+	if opened || prefs.Failures > 0 {
+		prefs.Failures = 0
+		prefs.UpdatedAt = time.Now()
+		if err := prefs.Save(); err != nil {
+			d.logger.Error("Error saving DM preferences", zap.Error(err), zap.Uint64("user_id", prefs.UserId))
+		}
+	}
+
+	return nil
+}
+
+// recordDmFailure counts a failed delivery against the user, disabling direct messages once the user has been
+// unreachable too many times in a row, such as after closing their DMs. err is returned for logging.
+func (d *Daemon) recordDmFailure(prefs model.DmPreferences, err error) error {
+	var restErr request.RestError
+	if !errors.As(err, &restErr) || restErr.StatusCode != 403 {
+		return err
+	}
+
+	prefs.Failures++
+	prefs.UpdatedAt = time.Now()
+	if prefs.Failures >= d.config.DirectMessages.MaxFailures {
+		prefs.Enabled = false
+		d.logger.Info("Disabling direct messages for unreachable user", zap.Uint64("user_id", prefs.UserId), zap.Int("failures", prefs.Failures))
+	}
+
+	if saveErr := prefs.Save(); saveErr != nil {
+		return errors.Join(err, saveErr)
+	}
+
+	return err
+}
@@ -0,0 +1,117 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/TicketsBot-cloud/gdl/objects/channel/message"
+	"github.com/TicketsBot-cloud/gdl/objects/interaction/component"
+	"github.com/TicketsBot-cloud/gdl/rest"
+	"github.com/TicketsBot-cloud/status-updates/internal/i18n"
+	"github.com/TicketsBot-cloud/status-updates/internal/model"
+	"go.uber.org/zap"
+)
+
+const (
+	noticeMaintenanceStarted   = "maintenance-started"
+	noticeMaintenanceCompleted = "maintenance-completed"
+	noticeResolution           = "resolution"
+	noticeResolutionMessage    = "resolution-message"
+)
+
+// maintenanceNotice is a one-off message posted to the incident thread of a scheduled maintenance
+type maintenanceNotice struct {
+	key   string
+	emoji string
+	title string
+}
+
+// dueMaintenanceNotices returns the notices that are due to be posted for the given destination, along with the keys
+// of notices that are no longer relevant and should be recorded as sent without being posted. Only the most recent of
+// several due reminders is posted, and notices for transitions that happened before the destination was announced are
+// skipped, as the announcement already reflects them.
+func dueMaintenanceNotices(incident model.Incident, dest model.IncidentDestination, sent map[string]bool, now time.Time) ([]maintenanceNotice, []string) {
+	var due []maintenanceNotice
+	var skipped []string
+
+	switch incident.Status {
+	case "scheduled":
+		var reminder *maintenanceNotice
+		for _, hours := range incident.ReminderHours() {
+			key := fmt.Sprintf("maintenance-reminder-%dh", hours)
+			remindAt := incident.ScheduledFor.Add(-time.Duration(hours) * time.Hour)
+			if sent[key] || now.Before(remindAt) || !now.Before(incident.ScheduledFor) {
+				continue
+			}
+
+			// Reminders are ordered largest interval first, so any earlier reminder is superseded by this one
+			if reminder != nil {
+				skipped = append(skipped, reminder.key)
+			}
+
+			if remindAt.Before(dest.CreatedAt) {
+				skipped = append(skipped, key)
+				reminder = nil
+				continue
+			}
+
+			reminder = &maintenanceNotice{key: key, emoji: "⏰", title: "Maintenance Reminder"}
+		}
+
+		if reminder != nil {
+			due = append(due, *reminder)
+		}
+	case "in_progress", "verifying":
+		if incident.ScheduledAutoInProgress && !sent[noticeMaintenanceStarted] {
+			if incident.ScheduledFor.Before(dest.CreatedAt) {
+				skipped = append(skipped, noticeMaintenanceStarted)
+			} else {
+				due = append(due, maintenanceNotice{key: noticeMaintenanceStarted, emoji: "🔧", title: "Maintenance Started"})
+			}
+		}
+	case "completed":
+		if !sent[noticeMaintenanceStarted] {
+			skipped = append(skipped, noticeMaintenanceStarted)
+		}
+
+		if incident.ScheduledAutoCompleted && !sent[noticeMaintenanceCompleted] {
+			if !incident.ScheduledUntil.IsZero() && incident.ScheduledUntil.Before(dest.CreatedAt) {
+				skipped = append(skipped, noticeMaintenanceCompleted)
+			} else {
+				due = append(due, maintenanceNotice{key: noticeMaintenanceCompleted, emoji: "✅", title: "Maintenance Completed"})
+			}
+		}
+	}
+
+	return due, skipped
+}
+
+// postMaintenanceNotices posts the given notices to the incident thread, recording each one so it is only posted once.
+func (d *Daemon) postMaintenanceNotices(ctx context.Context, incident model.Incident, dest destination, notices []maintenanceNotice) error {
+	for _, notice := range notices {
+		_, err := d.discord.CreateMessage(ctx, dest.ThreadId, rest.CreateMessageData{
+			Components: []component.Component{
+				component.BuildTextDisplay(component.TextDisplay{
+					Content: "-# " + notice.emoji + " " + i18n.T(incident.Locale, notice.title) + roleMention(dest.RoleId),
+				}),
+				incident.GenerateMaintenanceNoticeContainer(notice.emoji, notice.title),
+			},
+			Flags: message.SumFlags(message.FlagComponentsV2),
+			AllowedMentions: message.AllowedMention{
+				Roles: []uint64{dest.RoleId},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("error creating maintenance notice %s in thread: %w", notice.key, err)
+		}
+
+		if err := d.notices.MarkNoticeSent(dest.IncidentDestination, notice.key); err != nil {
+			return fmt.Errorf("error marking notice %s as sent: %w", notice.key, err)
+		}
+
+		d.logger.Info("Maintenance notice sent in thread", zap.String("incident_id", incident.ID), zap.String("notice", notice.key), zap.Uint64("thread_id", dest.ThreadId))
+	}
+
+	return nil
+}
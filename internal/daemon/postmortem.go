@@ -0,0 +1,132 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TicketsBot-cloud/gdl/objects/channel/message"
+	"github.com/TicketsBot-cloud/gdl/objects/interaction/component"
+	"github.com/TicketsBot-cloud/gdl/rest"
+	"github.com/TicketsBot-cloud/status-updates/internal/i18n"
+	"github.com/TicketsBot-cloud/status-updates/internal/model"
+	"go.uber.org/zap"
+)
+
+const noticePostmortem = "postmortem"
+
+// postmortemChunkNotice is the notice recorded once the chunk of a postmortem at idx has been posted, so that a
+// postmortem interrupted part way through resumes from the first chunk not yet posted.
+func postmortemChunkNotice(idx int) string {
+	return fmt.Sprintf("%s-%d", noticePostmortem, idx)
+}
+
+// deliverPostmortem posts the postmortem of a resolved incident to the destination once it has been published. The
+// incident thread is reopened for the postmortem and closed again afterwards; if it cannot be reopened, the postmortem
+// is posted in the channel as a reply to the announcement instead.
+func (d *Daemon) deliverPostmortem(ctx context.Context, feed Feed, incident model.Incident, dest destination) error {
+	if !incident.HasPostmortem() || !isResolved(incident.Status) {
+		return nil
+	}
+
+	sent, err := d.notices.SentNotices(dest.IncidentDestination)
+	if err != nil {
+		return fmt.Errorf("error retrieving sent notices: %w", err)
+	}
+
+	if sent[noticePostmortem] {
+		return nil
+	}
+
+	channelId := dest.ThreadId
+	var reference *message.MessageReference
+	reopened := false
+	if !feed.Config.KeepThreadsOpen {
+		if err := d.setThreadClosed(ctx, dest.ThreadId, false); err != nil {
+			d.logger.Warn("Error reopening incident thread, posting postmortem in channel instead", zap.Error(err),
+				zap.String("incident_id", incident.ID),
+				zap.Uint64("thread_id", dest.ThreadId),
+			)
+			channelId = dest.ChannelId
+			reference = &message.MessageReference{
+				MessageId: dest.MessageId,
+				ChannelId: dest.ChannelId,
+				GuildId:   dest.GuildId,
+			}
+		} else {
+			reopened = true
+		}
+	}
+
+	// Only ping the update role if the status page notified its own subscribers of the postmortem
+	var mention string
+	var roles []uint64
+	if incident.PostmortemNotifiedSubscribers && dest.Subscription.RoleId != 0 {
+		mention = roleMention(dest.Subscription.RoleId)
+		roles = []uint64{dest.Subscription.RoleId}
+	}
+
+	var postErr error
+	for idx, container := range incident.GeneratePostmortemContainers() {
+		if sent[postmortemChunkNotice(idx)] {
+			continue
+		}
+
+		components := []component.Component{container}
+		if idx == 0 {
+			components = append([]component.Component{component.BuildTextDisplay(component.TextDisplay{
+				Content: "-# " + i18n.T(incident.Locale, "A postmortem has been published") + mention,
+			})}, components...)
+		}
+
+		data := rest.CreateMessageData{
+			Components: components,
+			Flags:      message.SumFlags(message.FlagComponentsV2),
+			AllowedMentions: message.AllowedMention{
+				Roles: roles,
+			},
+		}
+		if idx == 0 {
+			data.MessageReference = reference
+		}
+
+		if _, err := d.discord.CreateMessage(ctx, channelId, data); err != nil {
+			postErr = fmt.Errorf("error creating postmortem message: %w", err)
+			break
+		}
+
+		if err := d.notices.MarkNoticeSent(dest.IncidentDestination, postmortemChunkNotice(idx)); err != nil {
+			postErr = fmt.Errorf("error marking postmortem chunk %d as sent: %w", idx, err)
+			break
+		}
+	}
+
+	if postErr == nil {
+		if err := d.notices.MarkNoticeSent(dest.IncidentDestination, noticePostmortem); err != nil {
+			postErr = fmt.Errorf("error marking postmortem as sent: %w", err)
+		}
+	}
+
+	if reopened {
+		if err := d.setThreadClosed(ctx, dest.ThreadId, true); err != nil {
+			d.logger.Error("Error closing thread", zap.Error(err))
+		}
+	}
+
+	if postErr != nil {
+		return postErr
+	}
+
+	d.logger.Info("Postmortem sent for incident", zap.String("incident_id", incident.ID), zap.Uint64("channel_id", channelId))
+	return nil
+}
+
+// setThreadClosed archives and locks the thread, or reopens it.
+func (d *Daemon) setThreadClosed(ctx context.Context, threadId uint64, closed bool) error {
+	_, err := d.discord.ModifyChannel(ctx, threadId, rest.ModifyChannelData{
+		ThreadMetadataModifyData: &rest.ThreadMetadataModifyData{
+			Archived: &closed,
+			Locked:   &closed,
+		},
+	})
+	return err
+}
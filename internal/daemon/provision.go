@@ -0,0 +1,165 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TicketsBot-cloud/gdl/objects/channel"
+	"github.com/TicketsBot-cloud/gdl/objects/channel/message"
+	"github.com/TicketsBot-cloud/gdl/objects/interaction/component"
+	"github.com/TicketsBot-cloud/gdl/rest"
+	"github.com/TicketsBot-cloud/status-updates/internal/model"
+	"go.uber.org/zap"
+)
+
+// provisionDestination creates the Discord resources for an incident in a destination channel one step at a time,
+// saving the destination after every completed step. If a step fails, the next run resumes from the last saved step
+// instead of starting over, so a failure part-way through never results in a duplicate announcement.
+func (d *Daemon) provisionDestination(ctx context.Context, feed Feed, incident model.Incident, dest *destination, msgComponents []component.Component) error {
+	for dest.State != model.IncidentStateReady {
+		var err error
+		switch dest.State {
+		case model.IncidentStateNew:
+			err = d.postAnnouncement(ctx, incident, dest, msgComponents)
+		case model.IncidentStateMessagePosted:
+			err = d.createIncidentRole(ctx, incident, dest)
+		case model.IncidentStateRoleCreated:
+			err = d.startIncidentThread(ctx, feed, incident, dest)
+		case model.IncidentStateThreadCreated:
+			d.addComponentSubscribers(ctx, incident, dest)
+			dest.State = model.IncidentStateReady
+		default:
+			return fmt.Errorf("incident %s has unknown state %q in channel %d", incident.ID, dest.State, dest.ChannelId)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := dest.Save(); err != nil {
+			return err
+		}
+
+		d.logger.Info("Incident provisioning step completed", zap.String("incident_id", incident.ID), zap.Uint64("channel_id", dest.ChannelId), zap.String("state", string(dest.State)))
+
+		// The announcement already contains every existing update, so they should not be re-posted to the thread
+		if dest.State == model.IncidentStateMessagePosted {
+			for _, update := range incident.IncidentUpdates {
+				if err := dest.MarkUpdateDelivered(update.ID); err != nil {
+					d.logger.Error("Error marking update as delivered", zap.Error(err), zap.String("update_id", update.ID))
+				}
+			}
+
+			d.crosspostAnnouncement(ctx, dest)
+		}
+	}
+
+	return nil
+}
+
+func (d *Daemon) postAnnouncement(ctx context.Context, incident model.Incident, dest *destination, msgComponents []component.Component) error {
+	d.logger.Info("New incident detected. Sending Discord message...",
+		zap.String("feed", dest.Subscription.Feed),
+		zap.String("incident_id", incident.ID),
+		zap.String("status", incident.Status),
+		zap.Uint64("guild_id", dest.GuildId),
+		zap.Uint64("channel_id", dest.ChannelId),
+	)
+
+	var allowedMentions message.AllowedMention
+	if dest.Subscription.RoleId != 0 {
+		allowedMentions.Roles = []uint64{dest.Subscription.RoleId}
+	}
+
+	msg, err := d.discord.CreateMessage(ctx, dest.ChannelId, rest.CreateMessageData{
+		Components:      msgComponents,
+		Flags:           message.SumFlags(message.FlagComponentsV2),
+		AllowedMentions: allowedMentions,
+	})
+	if err != nil {
+		return fmt.Errorf("error sending message: %w", err)
+	}
+
+	d.logger.Info("Discord message sent for incident", zap.String("incident_id", incident.ID), zap.Uint64("message_id", msg.Id))
+
+	dest.MessageId = msg.Id
+	dest.State = model.IncidentStateMessagePosted
+	return nil
+}
+
+// crosspostAnnouncement publishes the announcement to following servers. Failures are only logged, as the
+// announcement has already been posted and should not be retried.
+func (d *Daemon) crosspostAnnouncement(ctx context.Context, dest *destination) {
+	if !dest.Subscription.ShouldCrosspost {
+		return
+	}
+
+	channelInfo, err := d.discord.GetChannel(ctx, dest.ChannelId)
+	if err != nil {
+		d.logger.Error("Error retrieving channel info", zap.Error(err))
+		return
+	}
+
+	if channelInfo.Type == channel.ChannelTypeGuildNews {
+		if err := d.discord.CrosspostMessage(ctx, dest.ChannelId, dest.MessageId); err != nil {
+			d.logger.Error("Error crossposting message", zap.Error(err))
+		}
+	}
+}
+
+func (d *Daemon) createIncidentRole(ctx context.Context, incident model.Incident, dest *destination) error {
+	role, err := d.discord.CreateGuildRole(ctx, dest.GuildId, rest.GuildRoleData{
+		Name: fmt.Sprintf("Incident Updates: %s", incident.ID),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating role: %w", err)
+	}
+
+	dest.RoleId = role.Id
+	dest.State = model.IncidentStateRoleCreated
+	return nil
+}
+
+func (d *Daemon) startIncidentThread(ctx context.Context, feed Feed, incident model.Incident, dest *destination) error {
+	thread, err := d.discord.StartThreadWithMessage(ctx, dest.ChannelId, dest.MessageId, rest.StartThreadWithMessageData{
+		Name:                fmt.Sprintf("Incident Updates: %s", incident.ID),
+		AutoArchiveDuration: feed.Config.ThreadAutoArchiveDuration,
+	})
+	if err != nil {
+		return fmt.Errorf("error starting thread: %w", err)
+	}
+
+	dest.ThreadId = thread.Id
+	dest.State = model.IncidentStateThreadCreated
+	return nil
+}
+
+// addComponentSubscribers adds the members who follow any of the incident's components to the incident role and
+// thread. Failures are only logged, as members can still opt in using the announcement's button.
+func (d *Daemon) addComponentSubscribers(ctx context.Context, incident model.Incident, dest *destination) {
+	componentIds := incident.ComponentIds()
+	if len(componentIds) == 0 {
+		return
+	}
+
+	userIds, err := model.GetComponentSubscribers(dest.GuildId, dest.Subscription.Feed, componentIds)
+	if err != nil {
+		d.logger.Error("Error retrieving component subscribers", zap.Error(err), zap.String("incident_id", incident.ID))
+		return
+	}
+
+	for _, userId := range userIds {
+		if err := d.discord.AddGuildMemberRole(ctx, dest.GuildId, userId, dest.RoleId); err != nil {
+			d.logger.Warn("Error adding component subscriber to role", zap.Error(err), zap.Uint64("user_id", userId))
+			continue
+		}
+
+		if err := d.discord.AddThreadMember(ctx, dest.ThreadId, userId); err != nil {
+			d.logger.Warn("Error adding component subscriber to thread", zap.Error(err), zap.Uint64("user_id", userId))
+		}
+	}
+
+	if len(userIds) > 0 {
+		d.logger.Info("Added component subscribers to incident", zap.String("incident_id", incident.ID), zap.Uint64("channel_id", dest.ChannelId), zap.Int("members", len(userIds)))
+	}
+}
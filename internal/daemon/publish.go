@@ -0,0 +1,93 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/TicketsBot-cloud/status-updates/internal/model"
+	"go.uber.org/zap"
+)
+
+// ErrPublishUnsupported is returned when publishing to a feed whose status page cannot be updated, such as a mirrored
+// public status page.
+var ErrPublishUnsupported = errors.New("feed does not support publishing updates")
+
+// incidentUpdater is implemented by incident sources that can post updates to incidents.
+type incidentUpdater interface {
+	UpdateIncident(ctx context.Context, incidentId string, data model.IncidentRequest) (model.Incident, error)
+}
+
+// PublishUpdate posts the content of a message in an incident thread to the status page as a new update to the
+// incident, with the incident's current status. The resulting update is linked to the message so it is not posted back
+// into the thread, and the incident is then processed so that the update reaches the announcement and every other
+// destination.
+func (d *Daemon) PublishUpdate(ctx context.Context, threadId, messageId uint64, body string) (model.Incident, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.config.Daemon.ExecutionTimeout)
+	defer cancel()
+
+	// Hold the lock while publishing, so that a run cannot post the new update to the thread before it is linked
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dest, err := model.GetDestinationByThread(threadId)
+	if err != nil {
+		return model.Incident{}, err
+	}
+
+	incidentInfo, err := model.Incident{ID: dest.IncidentId, Source: dest.Feed, PageID: dest.PageId}.Get()
+	if err != nil {
+		return model.Incident{}, err
+	}
+
+	var feed Feed
+	var found bool
+	for _, f := range d.feeds {
+		if f.Config.Name == incidentInfo.Source {
+			feed, found = f, true
+			break
+		}
+	}
+
+	if !found {
+		return model.Incident{}, fmt.Errorf("incident %s belongs to unknown feed %q", incidentInfo.Id, incidentInfo.Source)
+	}
+
+	updater, ok := feed.Source.(incidentUpdater)
+	if !ok {
+		return model.Incident{}, ErrPublishUnsupported
+	}
+
+	previous, err := feed.Source.GetIncident(ctx, incidentInfo.Id)
+	if err != nil {
+		return model.Incident{}, err
+	}
+
+	known := make(map[string]bool, len(previous.IncidentUpdates))
+	for _, update := range previous.IncidentUpdates {
+		known[update.ID] = true
+	}
+
+	incident, err := updater.UpdateIncident(ctx, incidentInfo.Id, model.IncidentRequest{
+		Status: previous.Status,
+		Body:   body,
+	})
+	if err != nil {
+		return model.Incident{}, err
+	}
+
+	for _, update := range incident.IncidentUpdates {
+		if known[update.ID] {
+			continue
+		}
+
+		if err := dest.MarkUpdatePublished(update.ID, messageId); err != nil {
+			return incident, err
+		}
+
+		d.logger.Info("Published update from incident thread", zap.String("incident_id", incident.ID), zap.String("update_id", update.ID), zap.Uint64("message_id", messageId))
+	}
+
+	d.processIncident(ctx, feed, incident)
+	return incident, nil
+}
@@ -0,0 +1,129 @@
+package daemon
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/TicketsBot-cloud/gdl/objects/channel/message"
+	"github.com/TicketsBot-cloud/gdl/objects/interaction/component"
+	"github.com/TicketsBot-cloud/gdl/rest"
+	"github.com/TicketsBot-cloud/gdl/rest/request"
+	"github.com/TicketsBot-cloud/status-updates/internal/model"
+	"go.uber.org/zap"
+)
+
+// updateStatusBoard keeps the status board of the feed up to date with the current status of its components and
+// active incidents. The board is created and pinned on first use, or if its message has been deleted, and is
+// otherwise only edited when its content changes.
+func (d *Daemon) updateStatusBoard(ctx context.Context, feed Feed, components []model.Component, incidents []model.Incident) error {
+	if feed.Config.StatusBoardChannelId == 0 {
+		return nil
+	}
+
+	var active []model.Incident
+	threads := make(map[string]uint64)
+	for _, incident := range incidents {
+		if isResolved(incident.Status) {
+			continue
+		}
+
+		active = append(active, incident)
+
+		destinations, err := model.GetIncidentDestinations(feed.Config.Name, feed.pageId(incident), incident.ID)
+		if err != nil {
+			return fmt.Errorf("error retrieving incident destinations: %w", err)
+		}
+
+		for _, dest := range destinations {
+			if dest.ChannelId == feed.Config.ChannelId && dest.ThreadId != 0 {
+				threads[incident.ID] = dest.ThreadId
+			}
+		}
+	}
+
+	// Sources don't guarantee an order, so sort incidents to keep the rendered content stable between runs
+	sort.SliceStable(active, func(a, b int) bool {
+		return active[a].CreatedAt.Before(active[b].CreatedAt)
+	})
+
+	// The last updated time is left out of the hash, as it would otherwise change on every run
+	hash, err := contentHash(model.GenerateStatusContainer(components, active, threads, time.Time{}, feed.Config.Locale))
+	if err != nil {
+		return fmt.Errorf("error hashing status board: %w", err)
+	}
+
+	board, exists, err := model.GetStatusBoard(feed.Config.Name)
+	if err != nil {
+		return fmt.Errorf("error retrieving status board: %w", err)
+	}
+
+	exists = exists && board.ChannelId == feed.Config.StatusBoardChannelId
+	if exists && board.ContentHash == hash {
+		return nil
+	}
+
+	msgComponents := []component.Component{model.GenerateStatusContainer(components, active, threads, time.Now(), feed.Config.Locale)}
+
+	if exists {
+		_, err := d.discord.EditMessage(ctx, board.ChannelId, board.MessageId, rest.EditMessageData{
+			Components: msgComponents,
+			Flags:      message.SumFlags(message.FlagComponentsV2),
+		})
+
+		var restErr request.RestError
+		if err != nil && (!errors.As(err, &restErr) || restErr.StatusCode != 404) {
+			return fmt.Errorf("error editing status board: %w", err)
+		}
+
+		if err == nil {
+			board.ContentHash = hash
+			board.UpdatedAt = time.Now()
+			return board.Save()
+		}
+
+		d.logger.Info("Status board message was deleted, recreating it", zap.String("feed", feed.Config.Name))
+	}
+
+	msg, err := d.discord.CreateMessage(ctx, feed.Config.StatusBoardChannelId, rest.CreateMessageData{
+		Components: msgComponents,
+		Flags:      message.SumFlags(message.FlagComponentsV2),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating status board: %w", err)
+	}
+
+	board = model.StatusBoard{
+		Feed:        feed.Config.Name,
+		ChannelId:   msg.ChannelId,
+		MessageId:   msg.Id,
+		ContentHash: hash,
+		UpdatedAt:   time.Now(),
+	}
+	if err := board.Save(); err != nil {
+		return fmt.Errorf("error saving status board: %w", err)
+	}
+
+	if err := d.discord.AddPinnedChannelMessage(ctx, msg.ChannelId, msg.Id); err != nil {
+		d.logger.Warn("Error pinning status board", zap.Error(err), zap.String("feed", feed.Config.Name))
+	}
+
+	d.logger.Info("Status board created", zap.String("feed", feed.Config.Name), zap.Uint64("message_id", msg.Id))
+	return nil
+}
+
+// contentHash returns a hash identifying the rendered content of a message component.
+func contentHash(c component.Component) (string, error) {
+	encoded, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
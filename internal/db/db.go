@@ -20,18 +20,197 @@ func InitDB() error {
 	schema := `
 	CREATE TABLE IF NOT EXISTS incidents (
 		id TEXT PRIMARY KEY,
-		role_id BIGINT NOT NULL,
-		message_id BIGINT NOT NULL,
-		thread_id BIGINT NOT NULL,
+		role_id BIGINT NOT NULL DEFAULT 0,
+		message_id BIGINT NOT NULL DEFAULT 0,
+		thread_id BIGINT NOT NULL DEFAULT 0,
 		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
 		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
 		status TEXT NOT NULL
 	);
+
+	ALTER TABLE incidents ADD COLUMN IF NOT EXISTS state TEXT NOT NULL DEFAULT 'ready';
+	ALTER TABLE incidents ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT 'statuspage';
+	ALTER TABLE incidents ADD COLUMN IF NOT EXISTS page_id TEXT NOT NULL DEFAULT '';
+
+	-- role_id, message_id, thread_id and state are superseded by incident_destinations and only read when migrating
+	ALTER TABLE incidents ALTER COLUMN role_id SET DEFAULT 0;
+	ALTER TABLE incidents ALTER COLUMN message_id SET DEFAULT 0;
+	ALTER TABLE incidents ALTER COLUMN thread_id SET DEFAULT 0;
+
+	CREATE TABLE IF NOT EXISTS subscriptions (
+		feed TEXT NOT NULL,
+		guild_id BIGINT NOT NULL,
+		channel_id BIGINT NOT NULL,
+		role_id BIGINT NOT NULL DEFAULT 0,
+		components TEXT[] NOT NULL DEFAULT '{}',
+		should_crosspost BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (feed, channel_id)
+	);
+
+	ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT '';
+
+	CREATE TABLE IF NOT EXISTS incident_destinations (
+		incident_id TEXT NOT NULL,
+		guild_id BIGINT NOT NULL,
+		channel_id BIGINT NOT NULL,
+		message_id BIGINT NOT NULL DEFAULT 0,
+		thread_id BIGINT NOT NULL DEFAULT 0,
+		role_id BIGINT NOT NULL DEFAULT 0,
+		state TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (incident_id, channel_id)
+	);
+
+	ALTER TABLE incident_destinations ADD COLUMN IF NOT EXISTS feed TEXT NOT NULL DEFAULT '';
+	ALTER TABLE incident_destinations ADD COLUMN IF NOT EXISTS page_id TEXT NOT NULL DEFAULT '';
+
+	-- Destinations created before they recorded their feed and page take them from their incident, which was then
+	-- unique by ID
+	UPDATE incident_destinations d SET feed = i.source, page_id = i.page_id
+	FROM incidents i WHERE d.feed = '' AND d.incident_id = i.id;
+
+	CREATE TABLE IF NOT EXISTS component_subscriptions (
+		guild_id BIGINT NOT NULL,
+		user_id BIGINT NOT NULL,
+		feed TEXT NOT NULL,
+		component_id TEXT NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (guild_id, user_id, feed, component_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS incident_followers (
+		incident_id TEXT NOT NULL,
+		user_id BIGINT NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (incident_id, user_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS dm_preferences (
+		user_id BIGINT PRIMARY KEY,
+		enabled BOOLEAN NOT NULL DEFAULT FALSE,
+		channel_id BIGINT NOT NULL DEFAULT 0,
+		failures INT NOT NULL DEFAULT 0,
+		enabled_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
+
+	ALTER TABLE dm_preferences ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT '';
+
+	CREATE TABLE IF NOT EXISTS dm_deliveries (
+		user_id BIGINT NOT NULL,
+		incident_id TEXT NOT NULL,
+		update_id TEXT NOT NULL,
+		delivered_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (user_id, incident_id, update_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS published_updates (
+		incident_id TEXT NOT NULL,
+		channel_id BIGINT NOT NULL,
+		update_id TEXT NOT NULL,
+		message_id BIGINT NOT NULL,
+		published_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (incident_id, channel_id, update_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS component_statuses (
+		feed TEXT NOT NULL,
+		component_id TEXT NOT NULL,
+		status TEXT NOT NULL,
+		pending_status TEXT NOT NULL DEFAULT '',
+		pending_since TIMESTAMP WITH TIME ZONE,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (feed, component_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS status_boards (
+		feed TEXT PRIMARY KEY,
+		channel_id BIGINT NOT NULL,
+		message_id BIGINT NOT NULL,
+		content_hash TEXT NOT NULL DEFAULT '',
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
+
+	CREATE TABLE IF NOT EXISTS incident_notices (
+		incident_id TEXT NOT NULL,
+		channel_id BIGINT NOT NULL,
+		notice TEXT NOT NULL,
+		sent_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (incident_id, channel_id, notice)
+	);
+
+	CREATE TABLE IF NOT EXISTS incident_updates (
+		incident_id TEXT NOT NULL,
+		channel_id BIGINT NOT NULL DEFAULT 0,
+		update_id TEXT NOT NULL,
+		delivered_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (incident_id, channel_id, update_id)
+	);
+
+	ALTER TABLE incident_updates ADD COLUMN IF NOT EXISTS channel_id BIGINT NOT NULL DEFAULT 0;
+
+	DO $$
+	BEGIN
+		IF NOT EXISTS (
+			SELECT 1 FROM information_schema.key_column_usage
+			WHERE table_name = 'incident_updates' AND constraint_name = 'incident_updates_pkey' AND column_name = 'channel_id'
+		) THEN
+			ALTER TABLE incident_updates DROP CONSTRAINT incident_updates_pkey;
+			ALTER TABLE incident_updates ADD PRIMARY KEY (incident_id, channel_id, update_id);
+		END IF;
+
+		-- Incidents are keyed by page as well as ID, and by the feed tracking them, so a page can be split across feeds
+		IF NOT EXISTS (
+			SELECT 1 FROM information_schema.key_column_usage
+			WHERE table_name = 'incidents' AND constraint_name = 'incidents_pkey' AND column_name = 'page_id'
+		) THEN
+			ALTER TABLE incidents DROP CONSTRAINT incidents_pkey;
+			ALTER TABLE incidents ADD PRIMARY KEY (source, page_id, id);
+		END IF;
+
+		IF NOT EXISTS (
+			SELECT 1 FROM information_schema.key_column_usage
+			WHERE table_name = 'incident_destinations' AND constraint_name = 'incident_destinations_pkey' AND column_name = 'page_id'
+		) THEN
+			ALTER TABLE incident_destinations DROP CONSTRAINT incident_destinations_pkey;
+			ALTER TABLE incident_destinations ADD PRIMARY KEY (feed, page_id, incident_id, channel_id);
+		END IF;
+	END $$;
 	`
 	_, err = Client.Exec(schema)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return migrateDestinations()
+}
+
+// migrateDestinations moves the Discord resources of incidents announced before destinations existed, which were
+// always posted to the configured guild and the channel of their feed, into incident_destinations.
+func migrateDestinations() error {
+	tx, err := Client.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, feed := range config.Conf.Feeds {
+		if _, err := tx.Exec(`INSERT INTO incident_destinations (feed, page_id, incident_id, guild_id, channel_id, message_id, thread_id, role_id, state)
+			SELECT source, page_id, id, $1, $2, message_id, thread_id, role_id, state FROM incidents WHERE source = $3 AND message_id <> 0
+			ON CONFLICT (feed, page_id, incident_id, channel_id) DO NOTHING`, config.Conf.Discord.GuildId, feed.ChannelId, feed.Name); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`UPDATE incident_updates SET channel_id = $1
+			WHERE channel_id = 0 AND incident_id IN (SELECT id FROM incidents WHERE source = $2)`, feed.ChannelId, feed.Name); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`UPDATE incidents SET message_id = 0, thread_id = 0, role_id = 0 WHERE source = $1 AND message_id <> 0`, feed.Name); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
@@ -3,6 +3,7 @@ package httpserver
 import (
 	"bytes"
 	"crypto/ed25519"
+	"crypto/subtle"
 	"encoding/hex"
 	"io/ioutil"
 
@@ -54,6 +55,23 @@ func (s *Server) AuthMiddleware(ctx *gin.Context) {
 	ctx.Next()
 }
 
+// WebhookAuthMiddleware verifies the shared secret on incoming Statuspage webhooks. Statuspage does not allow custom
+// headers to be configured, so the secret may be provided either in the X-Webhook-Secret header or the secret query
+// parameter of the webhook URL.
+func (s *Server) WebhookAuthMiddleware(ctx *gin.Context) {
+	secret := ctx.GetHeader("X-Webhook-Secret")
+	if secret == "" {
+		secret = ctx.Query("secret")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(s.config.StatusPage.WebhookSecret)) != 1 {
+		ctx.AbortWithStatusJSON(401, errorJson("Invalid webhook secret"))
+		return
+	}
+
+	ctx.Next()
+}
+
 func errorJson(message string) gin.H {
 	return gin.H{
 		"error": message,
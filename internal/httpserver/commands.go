@@ -0,0 +1,192 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TicketsBot-cloud/gdl/objects/interaction"
+	"github.com/TicketsBot-cloud/gdl/rest"
+	"github.com/TicketsBot-cloud/status-updates/internal/source"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// commands returns the application commands handled by the server.
+func (s *Server) commands() []rest.CreateCommandData {
+	commands := []rest.CreateCommandData{
+		{
+			Name:        "status",
+			Description: "Show the current status of every component",
+			Type:        interaction.ApplicationCommandTypeChatInput,
+			Options:     pageOptions(s.sources, "The status page to show"),
+		},
+		{
+			Name:        "subscriptions",
+			Description: "Choose components to automatically receive incident updates for",
+			Type:        interaction.ApplicationCommandTypeChatInput,
+			Options:     pageOptions(s.sources, "The status page to choose components from"),
+		},
+		{
+			Name:        "dm",
+			Description: "Receive updates for incidents you follow by direct message",
+			Type:        interaction.ApplicationCommandTypeChatInput,
+			Options: []interaction.ApplicationCommandOption{{
+				Type:        interaction.OptionTypeBoolean,
+				Name:        "enabled",
+				Description: "Whether to receive updates by direct message",
+				Required:    true,
+			}},
+		},
+	}
+
+	if managers := s.managedSources(); s.config.Discord.StaffRoleId != 0 && len(managers) > 0 {
+		commands = append(commands, rest.CreateCommandData{
+			Name:        "incident",
+			Description: "Create or update an incident on the status page",
+			Type:        interaction.ApplicationCommandTypeChatInput,
+			Options: []interaction.ApplicationCommandOption{
+				{
+					Type:        interaction.OptionTypeSubCommand,
+					Name:        "create",
+					Description: "Create a new incident",
+					Options:     pageOptions(managers, "The status page to create the incident on"),
+				},
+				{
+					Type:        interaction.OptionTypeSubCommand,
+					Name:        "update",
+					Description: "Post an update to an existing incident",
+					Options: append([]interaction.ApplicationCommandOption{{
+						Type:        interaction.OptionTypeString,
+						Name:        "incident",
+						Description: "The ID of the incident to update",
+						Required:    true,
+					}}, pageOptions(managers, "The status page the incident is on")...),
+				},
+			},
+		}, rest.CreateCommandData{
+			Name: publishCommandName,
+			Type: interaction.ApplicationCommandTypeMessage,
+		})
+	}
+
+	return commands
+}
+
+// pageOptions returns an option for choosing which of the given feeds' status page a command applies to. It is only
+// offered if there is more than one feed to choose from.
+func pageOptions[T interface{ Name() string }](sources []T, description string) []interaction.ApplicationCommandOption {
+	if len(sources) <= 1 {
+		return nil
+	}
+
+	choices := make([]interaction.ApplicationCommandOptionChoice, 0, len(sources))
+	for _, src := range sources {
+		choices = append(choices, interaction.ApplicationCommandOptionChoice{Name: src.Name(), Value: src.Name()})
+	}
+
+	return []interaction.ApplicationCommandOption{{
+		Type:        interaction.OptionTypeString,
+		Name:        "page",
+		Description: description,
+		Choices:     choices,
+	}}
+}
+
+// sourceByName returns the incident source of the named feed.
+func (s *Server) sourceByName(name string) (source.IncidentSource, bool) {
+	for _, src := range s.sources {
+		if src.Name() == name {
+			return src, true
+		}
+	}
+
+	return nil, false
+}
+
+// selectedSource returns the incident source chosen by the page option, defaulting to the first feed.
+func (s *Server) selectedSource(options []interaction.ApplicationCommandInteractionDataOption) (source.IncidentSource, bool) {
+	if name, ok := stringOption(options, "page"); ok {
+		return s.sourceByName(name)
+	}
+
+	if len(s.sources) == 0 {
+		return nil, false
+	}
+
+	return s.sources[0], true
+}
+
+// RegisterCommands overwrites the bot's global application commands with those handled by the server.
+func (s *Server) RegisterCommands(ctx context.Context) error {
+	applicationId := s.config.Discord.ApplicationId
+	if applicationId == 0 {
+		application, err := rest.GetCurrentApplication(ctx, s.config.Discord.Token, nil)
+		if err != nil {
+			return fmt.Errorf("error retrieving application: %w", err)
+		}
+
+		applicationId = application.Id
+	}
+
+	commands, err := rest.ModifyGlobalCommands(ctx, s.config.Discord.Token, nil, applicationId, s.commands())
+	if err != nil {
+		return fmt.Errorf("error registering commands: %w", err)
+	}
+
+	s.logger.Info("Registered application commands", zap.Int("count", len(commands)))
+	return nil
+}
+
+func (s *Server) handleApplicationCommand(ctx *gin.Context) {
+	var commandData interaction.ApplicationCommandInteraction
+	if err := ctx.ShouldBindBodyWith(&commandData, binding.JSON); err != nil {
+		_ = ctx.Error(errors.Wrap(err, "failed to parse application command payload"))
+		return
+	}
+
+	if commandData.Data == nil {
+		ctx.JSON(400, errorJson("Missing command data"))
+		return
+	}
+
+	switch commandData.Data.Name {
+	case "status":
+		s.handleStatusCommand(ctx, commandData)
+	case "subscriptions":
+		s.handleSubscriptionsCommand(ctx, commandData)
+	case "dm":
+		s.handleDmCommand(ctx, commandData)
+	case "incident":
+		s.handleIncidentCommand(ctx, commandData)
+	case publishCommandName:
+		s.handlePublishCommand(ctx, commandData)
+	default:
+		ctx.JSON(400, errorJson("Unknown command"))
+	}
+}
+
+// stringOption returns the value of the named string option, if it was provided.
+func stringOption(options []interaction.ApplicationCommandInteractionDataOption, name string) (string, bool) {
+	for _, option := range options {
+		if option.Name == name {
+			value, ok := option.Value.(string)
+			return value, ok
+		}
+	}
+
+	return "", false
+}
+
+// boolOption returns the value of the named boolean option, if it was provided.
+func boolOption(options []interaction.ApplicationCommandInteractionDataOption, name string) (bool, bool) {
+	for _, option := range options {
+		if option.Name == name {
+			value, ok := option.Value.(bool)
+			return value, ok
+		}
+	}
+
+	return false, false
+}
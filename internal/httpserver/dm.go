@@ -0,0 +1,54 @@
+package httpserver
+
+import (
+	"time"
+
+	"github.com/TicketsBot-cloud/gdl/objects/interaction"
+	"github.com/TicketsBot-cloud/status-updates/internal/i18n"
+	"github.com/TicketsBot-cloud/status-updates/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) handleDmCommand(ctx *gin.Context, commandData interaction.ApplicationCommandInteraction) {
+	var userId uint64
+	if commandData.Member != nil {
+		userId = commandData.Member.User.Id
+	} else if commandData.User != nil {
+		userId = commandData.User.Id
+	} else {
+		ctx.JSON(400, errorJson("Missing user"))
+		return
+	}
+
+	enabled, ok := boolOption(commandData.Data.Options, "enabled")
+	if !ok {
+		ctx.JSON(400, errorJson("Missing enabled option"))
+		return
+	}
+
+	prefs, err := model.GetDmPreferences(userId)
+	if err != nil {
+		ctx.JSON(500, errorJson("Failed to retrieve DM preferences"))
+		return
+	}
+
+	if enabled && !prefs.Enabled {
+		prefs.EnabledAt = time.Now()
+		prefs.Failures = 0
+	}
+
+	prefs.Enabled = enabled
+	prefs.Locale = commandData.Locale
+	prefs.UpdatedAt = time.Now()
+	if err := prefs.Save(); err != nil {
+		ctx.JSON(500, errorJson("Failed to save DM preferences"))
+		return
+	}
+
+	content := i18n.T(commandData.Locale, "You will no longer receive incident updates by direct message.")
+	if enabled {
+		content = i18n.T(commandData.Locale, "You will now receive updates by direct message for incidents you follow, either with the **Receive Updates** button or through `/subscriptions`. Make sure you allow direct messages from this server, or they will be disabled again.")
+	}
+
+	ctx.JSON(200, ephemeralMessage(content))
+}
@@ -8,7 +8,9 @@ import (
 	"github.com/TicketsBot-cloud/gdl/objects/interaction"
 	"github.com/TicketsBot-cloud/gdl/objects/interaction/component"
 	"github.com/TicketsBot-cloud/gdl/rest"
+	"github.com/TicketsBot-cloud/gdl/rest/request"
 	"github.com/TicketsBot-cloud/status-updates/internal/db"
+	"github.com/TicketsBot-cloud/status-updates/internal/i18n"
 	"github.com/TicketsBot-cloud/status-updates/internal/model"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
@@ -26,6 +28,8 @@ func (s *Server) HandleInteraction(ctx *gin.Context) {
 	switch body.Type {
 	case interaction.InteractionTypePing:
 		ctx.JSON(200, interaction.NewResponsePong())
+	case interaction.InteractionTypeApplicationCommand:
+		s.handleApplicationCommand(ctx)
 	case interaction.InteractionTypeMessageComponent:
 		var commandData interaction.MessageComponentInteraction
 		if err := ctx.ShouldBindBodyWith(&commandData, binding.JSON); err != nil {
@@ -33,46 +37,49 @@ func (s *Server) HandleInteraction(ctx *gin.Context) {
 			return
 		}
 
-		if strings.HasPrefix(commandData.Data.AsButton().CustomId, "incident-role-") {
-			incidentId := strings.TrimPrefix(commandData.Data.AsButton().CustomId, "incident-role-")
-			var incident model.IncidentInfo
+		if commandData.Data.IMessageComponentInteractionData == nil {
+			ctx.JSON(400, errorJson("Missing component data"))
+			return
+		}
 
-			if err := db.Client.Get(&incident, "SELECT * FROM incidents WHERE id = $1", incidentId); err != nil {
-				if errors.Is(err, sql.ErrNoRows) {
-					ctx.JSON(404, errorJson("Incident not found"))
-					return
-				}
-				_ = ctx.Error(errors.Wrap(err, "failed to fetch incident from database"))
+		if commandData.Data.Type() == component.ComponentSelectMenu {
+			customId := commandData.Data.AsSelectMenu().CustomId
+			if strings.HasPrefix(customId, "component-subscriptions-") {
+				s.handleSubscriptionsSelect(ctx, commandData, strings.TrimPrefix(customId, "component-subscriptions-"))
 				return
 			}
 
-			// Add incident updates role
-			if err := rest.AddGuildMemberRole(ctx, s.config.Discord.Token, nil, s.config.Discord.GuildId, commandData.Member.User.Id, incident.RoleId); err != nil {
-				ctx.JSON(500, errorJson("Failed to add role"))
-				s.logger.Error("Failed to add role", zap.Error(err))
-				return
-			}
+			ctx.JSON(400, gin.H{"error": "not found"})
+			return
+		}
 
-			// Add to thread
-			if err := rest.AddThreadMember(ctx, s.config.Discord.Token, nil, incident.ThreadId, commandData.Member.User.Id); err != nil {
-				ctx.JSON(500, errorJson("Failed to add to thread"))
-				s.logger.Error("Failed to add to thread", zap.Error(err))
-				return
-			}
+		customId := commandData.Data.AsButton().CustomId
+		switch {
+		case strings.HasPrefix(customId, "incident-role-"):
+			s.handleIncidentRoleButton(ctx, commandData, strings.TrimPrefix(customId, "incident-role-"), false)
+			return
+		case strings.HasPrefix(customId, "incident-unsubscribe-"):
+			s.handleIncidentRoleButton(ctx, commandData, strings.TrimPrefix(customId, "incident-unsubscribe-"), true)
+			return
+		}
 
-			ctx.JSON(200, interaction.NewResponseChannelMessage(interaction.ApplicationCommandCallbackData{
-				Flags: message.SumFlags(message.FlagEphemeral, message.FlagComponentsV2),
-				Components: []component.Component{
-					component.BuildContainer(component.Container{
-						Components: []component.Component{
-							component.BuildTextDisplay(component.TextDisplay{
-								Content: "You have been added to the incident updates role and thread.",
-							}),
-						},
-					}),
-				},
-			}))
+		ctx.JSON(400, gin.H{"error": "not found"})
+	case interaction.InteractionTypeModalSubmit:
+		var modalData interaction.ModalSubmitInteraction
+		if err := ctx.ShouldBindBodyWith(&modalData, binding.JSON); err != nil {
+			_ = ctx.Error(errors.Wrap(err, "failed to parse modal submit payload"))
+			return
+		}
 
+		customId := modalData.Data.CustomId
+		switch {
+		case strings.HasPrefix(customId, "incident-create-"):
+			s.handleIncidentModal(ctx, modalData, strings.TrimPrefix(customId, "incident-create-"), "")
+			return
+		case strings.HasPrefix(customId, "incident-update-"):
+			// Incident IDs never contain a hyphen, unlike feed names
+			incidentId, feed, _ := strings.Cut(strings.TrimPrefix(customId, "incident-update-"), "-")
+			s.handleIncidentModal(ctx, modalData, feed, incidentId)
 			return
 		}
 
@@ -80,3 +87,98 @@ func (s *Server) HandleInteraction(ctx *gin.Context) {
 	}
 
 }
+
+// handleIncidentRoleButton toggles whether the member receives updates for an incident, by adding or removing the
+// incident role and thread membership. If unsubscribe is set, the member is only ever removed.
+func (s *Server) handleIncidentRoleButton(ctx *gin.Context, commandData interaction.MessageComponentInteraction, incidentId string, unsubscribe bool) {
+	if commandData.Member == nil {
+		ctx.JSON(400, errorJson("Buttons can only be used in a server"))
+		return
+	}
+
+	var incident model.IncidentDestination
+
+	// The button is on the announcement, so the channel identifies which guild's role and thread to use
+	if err := db.Client.Get(&incident, "SELECT * FROM incident_destinations WHERE incident_id = $1 AND channel_id = $2", incidentId, commandData.ChannelId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(404, errorJson("Incident not found"))
+			return
+		}
+		_ = ctx.Error(errors.Wrap(err, "failed to fetch incident from database"))
+		return
+	}
+
+	// The role and thread may not have been created yet if provisioning is still in progress
+	if incident.State != model.IncidentStateReady {
+		ctx.JSON(409, errorJson("Incident is still being set up"))
+		return
+	}
+
+	userId := commandData.Member.User.Id
+	subscribed := commandData.Member.HasRole(incident.RoleId)
+
+	var content string
+	if subscribed || unsubscribe {
+		// Remove incident updates role
+		if err := rest.RemoveGuildMemberRole(ctx, s.config.Discord.Token, nil, incident.GuildId, userId, incident.RoleId); err != nil {
+			ctx.JSON(500, errorJson("Failed to remove role"))
+			s.logger.Error("Failed to remove role", zap.Error(err))
+			return
+		}
+
+		// Remove from thread
+		if err := rest.RemoveThreadMember(ctx, s.config.Discord.Token, nil, incident.ThreadId, userId); err != nil && !isNotFound(err) {
+			ctx.JSON(500, errorJson("Failed to remove from thread"))
+			s.logger.Error("Failed to remove from thread", zap.Error(err))
+			return
+		}
+
+		if err := model.UnfollowIncident(incident.IncidentId, userId); err != nil {
+			ctx.JSON(500, errorJson("Failed to unfollow incident"))
+			return
+		}
+
+		content = i18n.T(commandData.Locale, "You will no longer receive updates for this incident. Click **Receive Updates** to opt back in.")
+	} else {
+		// Add incident updates role
+		if err := rest.AddGuildMemberRole(ctx, s.config.Discord.Token, nil, incident.GuildId, userId, incident.RoleId); err != nil {
+			ctx.JSON(500, errorJson("Failed to add role"))
+			s.logger.Error("Failed to add role", zap.Error(err))
+			return
+		}
+
+		// Add to thread
+		if err := rest.AddThreadMember(ctx, s.config.Discord.Token, nil, incident.ThreadId, userId); err != nil {
+			ctx.JSON(500, errorJson("Failed to add to thread"))
+			s.logger.Error("Failed to add to thread", zap.Error(err))
+			return
+		}
+
+		if err := model.FollowIncident(incident.IncidentId, userId); err != nil {
+			ctx.JSON(500, errorJson("Failed to follow incident"))
+			return
+		}
+
+		content = i18n.T(commandData.Locale, "You have been added to the incident updates role and thread. Click **Receive Updates** again to stop receiving updates.")
+	}
+
+	ctx.JSON(200, interaction.NewResponseChannelMessage(interaction.ApplicationCommandCallbackData{
+		Flags: message.SumFlags(message.FlagEphemeral, message.FlagComponentsV2),
+		Components: []component.Component{
+			component.BuildContainer(component.Container{
+				Components: []component.Component{
+					component.BuildTextDisplay(component.TextDisplay{
+						Content: content,
+					}),
+				},
+			}),
+		},
+	}))
+}
+
+// isNotFound reports whether err is a Discord API error for a resource that does not exist, such as a member who has
+// already left a thread.
+func isNotFound(err error) bool {
+	var restErr request.RestError
+	return errors.As(err, &restErr) && restErr.StatusCode == 404
+}
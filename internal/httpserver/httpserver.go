@@ -1,22 +1,53 @@
 package httpserver
 
 import (
+	"context"
+	"errors"
+	"net/http"
+
 	"github.com/TicketsBot-cloud/status-updates/internal/config"
+	"github.com/TicketsBot-cloud/status-updates/internal/model"
+	"github.com/TicketsBot-cloud/status-updates/internal/source"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+// IncidentProcessor processes incidents received outside of the daemon's polling loop.
+type IncidentProcessor interface {
+	// ProcessIncident runs the incident from the named feed through the same pipeline used when polling.
+	ProcessIncident(ctx context.Context, feedName string, incident model.Incident)
+	// Trigger requests an immediate poll.
+	Trigger()
+	// PublishUpdate posts the content of a message in an incident thread to the status page as an incident update.
+	PublishUpdate(ctx context.Context, threadId, messageId uint64, body string) (model.Incident, error)
+}
+
+// IncidentManager creates and updates incidents on a status page we own.
+type IncidentManager interface {
+	Name() string
+	CreateIncident(ctx context.Context, data model.IncidentRequest) (model.Incident, error)
+	UpdateIncident(ctx context.Context, incidentId string, data model.IncidentRequest) (model.Incident, error)
+	ListComponents(ctx context.Context) ([]model.Component, error)
+}
+
 // Server represents the HTTP server with configuration and logging.
 type Server struct {
-	logger *zap.Logger   // logger is used for structured logging
-	config config.Config // config holds the server configuration
+	ctx       context.Context         // ctx is cancelled when the server shuts down, ending any work done in the background
+	logger    *zap.Logger             // logger is used for structured logging
+	config    config.Config           // config holds the server configuration
+	processor IncidentProcessor       // processor handles incidents received via webhooks
+	sources   []source.IncidentSource // sources are the status pages of each feed, used to answer commands
 }
 
-// NewServer creates a new Server instance with the provided logger and configuration.
-func NewServer(logger *zap.Logger, conf config.Config) *Server {
+// NewServer creates a new Server instance with the provided logger, configuration, incident processor and the
+// incident source of each feed. The server shuts down once ctx is cancelled.
+func NewServer(ctx context.Context, logger *zap.Logger, conf config.Config, processor IncidentProcessor, sources ...source.IncidentSource) *Server {
 	return &Server{
-		logger: logger,
-		config: conf,
+		ctx:       ctx,
+		logger:    logger,
+		config:    conf,
+		processor: processor,
+		sources:   sources,
 	}
 }
 
@@ -29,5 +60,25 @@ func (s *Server) Start() error {
 
 	router.POST("/interactions", s.AuthMiddleware, s.HandleInteraction)
 
-	return router.Run(s.config.ServerAddr)
+	if s.config.StatusPage.WebhookSecret != "" {
+		router.POST("/webhooks/statuspage", s.WebhookAuthMiddleware, s.HandleStatusPageWebhook)
+	}
+
+	server := &http.Server{
+		Addr:    s.config.ServerAddr,
+		Handler: router,
+	}
+
+	go func() {
+		<-s.ctx.Done()
+		if err := server.Shutdown(context.Background()); err != nil {
+			s.logger.Error("Failed to shut down HTTP server", zap.Error(err))
+		}
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
 }
@@ -0,0 +1,269 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/TicketsBot-cloud/gdl/objects/channel/message"
+	"github.com/TicketsBot-cloud/gdl/objects/interaction"
+	"github.com/TicketsBot-cloud/gdl/objects/interaction/component"
+	"github.com/TicketsBot-cloud/gdl/objects/member"
+	"github.com/TicketsBot-cloud/gdl/rest"
+	"github.com/TicketsBot-cloud/status-updates/internal/i18n"
+	"github.com/TicketsBot-cloud/status-updates/internal/model"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// incidentCommandTimeout bounds how long creating or updating an incident may take once the modal is submitted. The
+// response is deferred, so this is not limited by Discord's 3 second deadline.
+const incidentCommandTimeout = time.Minute
+
+var (
+	incidentStatuses  = []string{"investigating", "identified", "monitoring", "resolved"}
+	incidentImpacts   = []string{"none", "minor", "major", "critical"}
+	componentStatuses = []string{"operational", "degraded_performance", "partial_outage", "major_outage", "under_maintenance"}
+)
+
+// managedSources returns the sources of feeds whose incidents can be created and updated from Discord.
+func (s *Server) managedSources() []IncidentManager {
+	var managers []IncidentManager
+	for _, src := range s.sources {
+		if manager, ok := src.(IncidentManager); ok {
+			managers = append(managers, manager)
+		}
+	}
+
+	return managers
+}
+
+// managerByName returns the incident manager of the named feed, or the only one if no name is given.
+func (s *Server) managerByName(name string) (IncidentManager, bool) {
+	managers := s.managedSources()
+	if name == "" {
+		if len(managers) == 0 {
+			return nil, false
+		}
+
+		return managers[0], true
+	}
+
+	for _, manager := range managers {
+		if manager.Name() == name {
+			return manager, true
+		}
+	}
+
+	return nil, false
+}
+
+// isStaff reports whether the member may create and update incidents.
+func (s *Server) isStaff(guildId uint64, m *member.Member) bool {
+	return s.config.Discord.StaffRoleId != 0 && m != nil && guildId == s.config.Discord.GuildId && m.HasRole(s.config.Discord.StaffRoleId)
+}
+
+func (s *Server) handleIncidentCommand(ctx *gin.Context, commandData interaction.ApplicationCommandInteraction) {
+	if !s.isStaff(commandData.GuildId.Value, commandData.Member) {
+		ctx.JSON(200, ephemeralMessage(i18n.T(commandData.Locale, "You do not have permission to manage incidents.")))
+		return
+	}
+
+	if len(commandData.Data.Options) == 0 {
+		ctx.JSON(400, errorJson("Missing subcommand"))
+		return
+	}
+
+	subCommand := commandData.Data.Options[0]
+	page, _ := stringOption(subCommand.Options, "page")
+	manager, ok := s.managerByName(page)
+	if !ok {
+		ctx.JSON(200, ephemeralMessage(i18n.T(commandData.Locale, "Unknown status page.")))
+		return
+	}
+
+	locale := commandData.Locale
+	switch subCommand.Name {
+	case "create":
+		ctx.JSON(200, interaction.NewModalResponse(fmt.Sprintf("incident-create-%s", manager.Name()), i18n.T(locale, "Create Incident"), []component.Component{
+			textInput("name", i18n.T(locale, "Name"), component.TextStyleShort, true, "", i18n.T(locale, "A short summary of the incident")),
+			textInput("status", i18n.T(locale, "Status"), component.TextStyleShort, true, "investigating", strings.Join(incidentStatuses, ", ")),
+			textInput("impact", i18n.T(locale, "Impact"), component.TextStyleShort, false, "", strings.Join(incidentImpacts, ", ")),
+			textInput("components", i18n.T(locale, "Affected Components"), component.TextStyleShort, false, "", i18n.T(locale, "Comma separated names, optionally with :status e.g. %s", "API:major_outage")),
+			textInput("body", i18n.T(locale, "Message"), component.TextStyleParagraph, true, "", i18n.T(locale, "What is happening?")),
+		}))
+	case "update":
+		incidentId, ok := stringOption(subCommand.Options, "incident")
+		if !ok || incidentId == "" || strings.Contains(incidentId, "-") {
+			ctx.JSON(200, ephemeralMessage(i18n.T(commandData.Locale, "Invalid incident ID.")))
+			return
+		}
+
+		ctx.JSON(200, interaction.NewModalResponse(fmt.Sprintf("incident-update-%s-%s", incidentId, manager.Name()), i18n.T(locale, "Update Incident"), []component.Component{
+			textInput("status", i18n.T(locale, "Status"), component.TextStyleShort, true, "", strings.Join(incidentStatuses, ", ")),
+			textInput("impact", i18n.T(locale, "Impact"), component.TextStyleShort, false, "", strings.Join(incidentImpacts, ", ")),
+			textInput("components", i18n.T(locale, "Affected Components"), component.TextStyleShort, false, "", i18n.T(locale, "Comma separated names, optionally with :status e.g. %s", "API:operational")),
+			textInput("body", i18n.T(locale, "Message"), component.TextStyleParagraph, true, "", i18n.T(locale, "What has changed?")),
+		}))
+	default:
+		ctx.JSON(400, errorJson("Unknown subcommand"))
+	}
+}
+
+// handleIncidentModal creates or updates an incident from a submitted modal. incidentId is empty when creating.
+func (s *Server) handleIncidentModal(ctx *gin.Context, modalData interaction.ModalSubmitInteraction, feed, incidentId string) {
+	if !s.isStaff(modalData.GuildId.Value, modalData.Member) {
+		ctx.JSON(200, ephemeralMessage(i18n.T(modalData.Locale, "You do not have permission to manage incidents.")))
+		return
+	}
+
+	manager, ok := s.managerByName(feed)
+	if !ok {
+		ctx.JSON(200, ephemeralMessage(i18n.T(modalData.Locale, "Unknown status page.")))
+		return
+	}
+
+	values := modalValues(modalData)
+	data := model.IncidentRequest{
+		Name:           values["name"],
+		Status:         strings.ToLower(values["status"]),
+		ImpactOverride: strings.ToLower(values["impact"]),
+		Body:           values["body"],
+	}
+
+	if !slices.Contains(incidentStatuses, data.Status) {
+		ctx.JSON(200, ephemeralMessage(i18n.T(modalData.Locale, "Invalid status, must be one of: %s.", strings.Join(incidentStatuses, ", "))))
+		return
+	}
+
+	if data.ImpactOverride != "" && !slices.Contains(incidentImpacts, data.ImpactOverride) {
+		ctx.JSON(200, ephemeralMessage(i18n.T(modalData.Locale, "Invalid impact, must be one of: %s.", strings.Join(incidentImpacts, ", "))))
+		return
+	}
+
+	// Talking to Statuspage may take longer than Discord allows for a response, so defer it and edit in the result
+	ctx.JSON(200, interaction.NewResponseAckWithSource(message.SumFlags(message.FlagEphemeral)))
+
+	go func() {
+		reqCtx, cancel := context.WithTimeout(s.ctx, incidentCommandTimeout)
+		defer cancel()
+
+		content, err := s.submitIncident(reqCtx, manager, incidentId, data, values["components"], modalData.Locale)
+		if err != nil {
+			s.logger.Error("Failed to submit incident", zap.String("feed", feed), zap.String("incident_id", incidentId), zap.Error(err))
+		}
+
+		if _, err := rest.EditOriginalInteractionResponse(reqCtx, modalData.Token, nil, modalData.ApplicationId, rest.WebhookEditBody{
+			Content: content,
+		}); err != nil {
+			s.logger.Error("Failed to edit interaction response", zap.Error(err))
+		}
+	}()
+}
+
+// submitIncident creates or updates the incident, returning a message describing the outcome to show to the user,
+// translated to the given locale.
+func (s *Server) submitIncident(ctx context.Context, manager IncidentManager, incidentId string, data model.IncidentRequest, components, locale string) (string, error) {
+	if strings.TrimSpace(components) != "" {
+		componentIds, statuses, err := resolveComponents(ctx, manager, components)
+		if err != nil {
+			return i18n.T(locale, "Invalid affected components: %s.", err), err
+		}
+
+		data.ComponentIds = componentIds
+		data.Components = statuses
+	}
+
+	var incident model.Incident
+	var err error
+	if incidentId == "" {
+		incident, err = manager.CreateIncident(ctx, data)
+	} else {
+		incident, err = manager.UpdateIncident(ctx, incidentId, data)
+	}
+
+	if err != nil {
+		return i18n.T(locale, "Failed to submit the incident to the status page, please try again."), err
+	}
+
+	// Announce the incident straight away rather than waiting for the next poll
+	go s.processor.ProcessIncident(context.Background(), manager.Name(), incident)
+
+	if incidentId == "" {
+		return i18n.T(locale, "Created incident **%s** (`%s`). It will be announced shortly.", incident.Name, incident.ID), nil
+	}
+
+	return i18n.T(locale, "Updated incident **%s** (`%s`).", incident.Name, incident.ID), nil
+}
+
+// resolveComponents parses a comma separated list of component names or IDs, each optionally followed by :status,
+// into the affected component IDs and the statuses to set.
+func resolveComponents(ctx context.Context, manager IncidentManager, input string) ([]string, map[string]string, error) {
+	components, err := manager.ListComponents(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to retrieve components: %w", err)
+	}
+
+	var componentIds []string
+	statuses := make(map[string]string)
+	for _, entry := range strings.Split(input, ",") {
+		name, status, _ := strings.Cut(strings.TrimSpace(entry), ":")
+		name, status = strings.TrimSpace(name), strings.ToLower(strings.TrimSpace(status))
+		if name == "" {
+			continue
+		}
+
+		idx := slices.IndexFunc(components, func(c model.Component) bool {
+			return !c.Group && (c.ID == name || strings.EqualFold(c.Name, name))
+		})
+		if idx == -1 {
+			return nil, nil, fmt.Errorf("unknown component %q", name)
+		}
+
+		if status != "" && !slices.Contains(componentStatuses, status) {
+			return nil, nil, fmt.Errorf("invalid status for %s, must be one of %s", name, strings.Join(componentStatuses, ", "))
+		}
+
+		id := components[idx].ID
+		componentIds = append(componentIds, id)
+		if status != "" {
+			statuses[id] = status
+		}
+	}
+
+	return componentIds, statuses, nil
+}
+
+// modalValues returns the submitted value of each text input in a modal, keyed by custom ID.
+func modalValues(modalData interaction.ModalSubmitInteraction) map[string]string {
+	values := make(map[string]string)
+	for _, row := range modalData.Data.Components {
+		for _, input := range row.Components {
+			values[input.CustomId] = strings.TrimSpace(input.Value)
+		}
+	}
+
+	return values
+}
+
+// textInput builds a modal action row containing a single text input.
+func textInput(customId, label string, style component.TextStyleTypes, required bool, value, placeholder string) component.Component {
+	input := component.InputText{
+		Style:    style,
+		CustomId: customId,
+		Label:    label,
+		Required: &required,
+	}
+
+	if value != "" {
+		input.Value = &value
+	}
+
+	if placeholder != "" {
+		input.Placeholder = &placeholder
+	}
+
+	return component.BuildActionRow(component.BuildInputText(input))
+}
@@ -0,0 +1,64 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/TicketsBot-cloud/gdl/objects"
+	"github.com/TicketsBot-cloud/gdl/objects/channel/message"
+	"github.com/TicketsBot-cloud/gdl/objects/interaction"
+	"github.com/TicketsBot-cloud/gdl/rest"
+	"github.com/TicketsBot-cloud/status-updates/internal/daemon"
+	"github.com/TicketsBot-cloud/status-updates/internal/i18n"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// publishCommandName is the name of the message context menu command that publishes a message as an incident update
+const publishCommandName = "Publish to status page"
+
+func (s *Server) handlePublishCommand(ctx *gin.Context, commandData interaction.ApplicationCommandInteraction) {
+	if !s.isStaff(commandData.GuildId.Value, commandData.Member) {
+		ctx.JSON(200, ephemeralMessage(i18n.T(commandData.Locale, "You do not have permission to manage incidents.")))
+		return
+	}
+
+	msg, ok := commandData.Data.Resolved.Messages[objects.Snowflake(commandData.Data.TargetId)]
+	if !ok {
+		ctx.JSON(400, errorJson("Missing target message"))
+		return
+	}
+
+	body := strings.TrimSpace(msg.Content)
+	if body == "" {
+		ctx.JSON(200, ephemeralMessage(i18n.T(commandData.Locale, "Only messages with text content can be published.")))
+		return
+	}
+
+	// Talking to Statuspage may take longer than Discord allows for a response, so defer it and edit in the result
+	ctx.JSON(200, interaction.NewResponseAckWithSource(message.SumFlags(message.FlagEphemeral)))
+
+	go func() {
+		reqCtx, cancel := context.WithTimeout(s.ctx, incidentCommandTimeout)
+		defer cancel()
+
+		var content string
+		incident, err := s.processor.PublishUpdate(reqCtx, commandData.ChannelId, msg.Id, body)
+		switch {
+		case err == nil:
+			content = i18n.T(commandData.Locale, "Published the message as an update to **%s**.", incident.Name)
+		case errors.Is(err, daemon.ErrPublishUnsupported):
+			content = i18n.T(commandData.Locale, "This incident's status page cannot be updated from Discord.")
+		default:
+			s.logger.Error("Failed to publish update", zap.Uint64("thread_id", commandData.ChannelId), zap.Uint64("message_id", msg.Id), zap.Error(err))
+			content = i18n.T(commandData.Locale, "Failed to publish the message. Make sure it is in an incident thread and try again.")
+		}
+
+		if _, err := rest.EditOriginalInteractionResponse(reqCtx, commandData.Token, nil, commandData.ApplicationId, rest.WebhookEditBody{
+			Content: content,
+		}); err != nil {
+			s.logger.Error("Failed to edit interaction response", zap.Error(err))
+		}
+	}()
+}
@@ -0,0 +1,72 @@
+package httpserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/TicketsBot-cloud/gdl/objects/channel/message"
+	"github.com/TicketsBot-cloud/gdl/objects/interaction"
+	"github.com/TicketsBot-cloud/gdl/objects/interaction/component"
+	"github.com/TicketsBot-cloud/status-updates/internal/i18n"
+	"github.com/TicketsBot-cloud/status-updates/internal/model"
+	"github.com/TicketsBot-cloud/status-updates/internal/source"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// statusCommandTimeout bounds how long the /status command may spend fetching from the status page, as Discord
+// requires interactions to be responded to within 3 seconds.
+const statusCommandTimeout = 2500 * time.Millisecond
+
+func (s *Server) handleStatusCommand(ctx *gin.Context, commandData interaction.ApplicationCommandInteraction) {
+	src, ok := s.selectedSource(commandData.Data.Options)
+	if !ok {
+		ctx.JSON(200, ephemeralMessage(i18n.T(commandData.Locale, "Unknown status page.")))
+		return
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, statusCommandTimeout)
+	defer cancel()
+
+	components, err := src.ListComponents(fetchCtx)
+	if err != nil {
+		s.logger.Error("Failed to list components", zap.String("feed", src.Name()), zap.Error(err))
+		ctx.JSON(200, ephemeralMessage(i18n.T(commandData.Locale, "Failed to retrieve the current status, please try again later.")))
+		return
+	}
+
+	incidents, err := src.ListIncidents(fetchCtx, source.SyncRecent)
+	if err != nil {
+		s.logger.Error("Failed to list incidents", zap.String("feed", src.Name()), zap.Error(err))
+		ctx.JSON(200, ephemeralMessage(i18n.T(commandData.Locale, "Failed to retrieve the current status, please try again later.")))
+		return
+	}
+
+	var active []model.Incident
+	for _, incident := range incidents {
+		if !model.IsResolvedStatus(incident.Status) && incident.Status != "scheduled" {
+			active = append(active, incident)
+		}
+	}
+
+	ctx.JSON(200, interaction.NewResponseChannelMessage(interaction.ApplicationCommandCallbackData{
+		Flags:      message.SumFlags(message.FlagComponentsV2),
+		Components: []component.Component{model.GenerateStatusContainer(components, active, nil, time.Now(), commandData.Locale)},
+	}))
+}
+
+// ephemeralMessage builds a response containing a single line of text, only visible to the user who interacted.
+func ephemeralMessage(content string) interaction.ResponseChannelMessage {
+	return interaction.NewResponseChannelMessage(interaction.ApplicationCommandCallbackData{
+		Flags: message.SumFlags(message.FlagEphemeral, message.FlagComponentsV2),
+		Components: []component.Component{
+			component.BuildContainer(component.Container{
+				Components: []component.Component{
+					component.BuildTextDisplay(component.TextDisplay{
+						Content: content,
+					}),
+				},
+			}),
+		},
+	})
+}
@@ -0,0 +1,191 @@
+package httpserver
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/TicketsBot-cloud/gdl/objects/channel/message"
+	"github.com/TicketsBot-cloud/gdl/objects/interaction"
+	"github.com/TicketsBot-cloud/gdl/objects/interaction/component"
+	"github.com/TicketsBot-cloud/status-updates/internal/i18n"
+	"github.com/TicketsBot-cloud/status-updates/internal/model"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxSelectOptions is the largest number of options Discord allows in a select menu
+	maxSelectOptions = 25
+	// maxSelectMenus is the most select menus sent in a single message
+	maxSelectMenus = 5
+)
+
+// subscriptionMenus splits the components that can be followed into the options of each select menu, in the order they
+// appear on the status page.
+func subscriptionMenus(components []model.Component) [][]model.Component {
+	components = slices.DeleteFunc(slices.Clone(components), func(c model.Component) bool {
+		return c.Group
+	})
+	slices.SortStableFunc(components, func(a, b model.Component) int {
+		return cmp.Compare(a.Position, b.Position)
+	})
+
+	var menus [][]model.Component
+	for menu := range slices.Chunk(components, maxSelectOptions) {
+		if len(menus) == maxSelectMenus {
+			break
+		}
+
+		menus = append(menus, menu)
+	}
+
+	return menus
+}
+
+func (s *Server) handleSubscriptionsCommand(ctx *gin.Context, commandData interaction.ApplicationCommandInteraction) {
+	if commandData.Member == nil {
+		ctx.JSON(200, ephemeralMessage(i18n.T(commandData.Locale, "Subscriptions can only be managed in a server.")))
+		return
+	}
+
+	src, ok := s.selectedSource(commandData.Data.Options)
+	if !ok {
+		ctx.JSON(200, ephemeralMessage(i18n.T(commandData.Locale, "Unknown status page.")))
+		return
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, statusCommandTimeout)
+	defer cancel()
+
+	components, err := src.ListComponents(fetchCtx)
+	if err != nil {
+		s.logger.Error("Failed to list components", zap.String("feed", src.Name()), zap.Error(err))
+		ctx.JSON(200, ephemeralMessage(i18n.T(commandData.Locale, "Failed to retrieve components, please try again later.")))
+		return
+	}
+
+	subscribed, err := model.GetComponentSubscriptions(commandData.GuildId.Value, commandData.Member.User.Id, src.Name())
+	if err != nil {
+		ctx.JSON(500, errorJson("Failed to retrieve subscriptions"))
+		return
+	}
+
+	menus := subscriptionMenus(components)
+	if len(menus) == 0 {
+		ctx.JSON(200, ephemeralMessage(i18n.T(commandData.Locale, "This status page has no components to subscribe to.")))
+		return
+	}
+
+	children := []component.Component{
+		component.BuildTextDisplay(component.TextDisplay{
+			Content: i18n.T(commandData.Locale, "Choose the components you want to follow. You will automatically be added to the role and thread of any new incident affecting them."),
+		}),
+	}
+
+	minValues := 0
+	for idx, menu := range menus {
+		options := make([]component.SelectOption, 0, len(menu))
+		for _, c := range menu {
+			options = append(options, component.SelectOption{
+				Label:   c.Name,
+				Value:   c.ID,
+				Default: slices.Contains(subscribed, c.ID),
+			})
+		}
+
+		placeholder := i18n.T(commandData.Locale, "Select components")
+		if len(menus) > 1 {
+			placeholder = i18n.T(commandData.Locale, "Select components (%d of %d)", idx+1, len(menus))
+		}
+
+		maxValues := len(options)
+		children = append(children, component.BuildActionRow(component.BuildSelectMenu(component.SelectMenu{
+			CustomId:    fmt.Sprintf("component-subscriptions-%d-%s", idx, src.Name()),
+			Options:     options,
+			Placeholder: placeholder,
+			MinValues:   &minValues,
+			MaxValues:   &maxValues,
+		})))
+	}
+
+	ctx.JSON(200, interaction.NewResponseChannelMessage(interaction.ApplicationCommandCallbackData{
+		Flags: message.SumFlags(message.FlagEphemeral, message.FlagComponentsV2),
+		Components: []component.Component{
+			component.BuildContainer(component.Container{
+				Components: children,
+			}),
+		},
+	}))
+}
+
+// handleSubscriptionsSelect saves the components chosen from one of the menus sent by the subscriptions command. The
+// custom ID holds the index of the menu followed by the name of the feed.
+func (s *Server) handleSubscriptionsSelect(ctx *gin.Context, commandData interaction.MessageComponentInteraction, customId string) {
+	if commandData.Member == nil {
+		ctx.JSON(200, ephemeralMessage(i18n.T(commandData.Locale, "Subscriptions can only be managed in a server.")))
+		return
+	}
+
+	// Menus sent before they were numbered can no longer be matched to the components they offered
+	menuIdx, feed, _ := strings.Cut(customId, "-")
+	idx, err := strconv.Atoi(menuIdx)
+	if err != nil {
+		ctx.JSON(200, ephemeralMessage(i18n.T(commandData.Locale, "This menu has expired, please run the command again.")))
+		return
+	}
+
+	src, ok := s.sourceByName(feed)
+	if !ok {
+		ctx.JSON(200, ephemeralMessage(i18n.T(commandData.Locale, "Unknown status page.")))
+		return
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, statusCommandTimeout)
+	defer cancel()
+
+	components, err := src.ListComponents(fetchCtx)
+	if err != nil {
+		s.logger.Error("Failed to list components", zap.String("feed", src.Name()), zap.Error(err))
+		ctx.JSON(200, ephemeralMessage(i18n.T(commandData.Locale, "Failed to retrieve components, please try again later.")))
+		return
+	}
+
+	menus := subscriptionMenus(components)
+	if idx < 0 || idx >= len(menus) {
+		ctx.JSON(200, ephemeralMessage(i18n.T(commandData.Locale, "The components of this status page have changed, please run the command again.")))
+		return
+	}
+
+	// Only the components offered by this menu are changed, so choices made in the other menus are kept
+	offered := make([]string, 0, len(menus[idx]))
+	for _, c := range menus[idx] {
+		offered = append(offered, c.ID)
+	}
+
+	selected := slices.DeleteFunc(commandData.Data.AsSelectMenu().Values, func(componentId string) bool {
+		return !slices.Contains(offered, componentId)
+	})
+
+	userId := commandData.Member.User.Id
+	if err := model.SetComponentSubscriptions(commandData.GuildId.Value, userId, feed, offered, selected); err != nil {
+		ctx.JSON(500, errorJson("Failed to save subscriptions"))
+		return
+	}
+
+	subscribed, err := model.GetComponentSubscriptions(commandData.GuildId.Value, userId, feed)
+	if err != nil {
+		ctx.JSON(500, errorJson("Failed to retrieve subscriptions"))
+		return
+	}
+
+	content := i18n.T(commandData.Locale, "You will no longer be automatically added to incidents.")
+	if len(subscribed) > 0 {
+		content = i18n.T(commandData.Locale, "You will now automatically be added to new incidents affecting %d component(s).", len(subscribed))
+	}
+
+	ctx.JSON(200, ephemeralMessage(content))
+}
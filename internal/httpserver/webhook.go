@@ -0,0 +1,85 @@
+package httpserver
+
+import (
+	"context"
+
+	"github.com/TicketsBot-cloud/status-updates/internal/config"
+	"github.com/TicketsBot-cloud/status-updates/internal/model"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// statusPageWebhook represents the payload of a Statuspage webhook notification. Incident notifications populate
+// Incident, while component notifications populate ComponentUpdate and Component.
+type statusPageWebhook struct {
+	Page struct {
+		ID                string `json:"id"`
+		StatusIndicator   string `json:"status_indicator"`
+		StatusDescription string `json:"status_description"`
+	} `json:"page"`
+	Incident        *model.Incident            `json:"incident"`
+	ComponentUpdate *statusPageComponentUpdate `json:"component_update"`
+	Component       *model.Component           `json:"component"`
+}
+
+// statusPageComponentUpdate represents a component status transition in a Statuspage webhook
+type statusPageComponentUpdate struct {
+	ID          string `json:"id"`
+	ComponentID string `json:"component_id"`
+	OldStatus   string `json:"old_status"`
+	NewStatus   string `json:"new_status"`
+}
+
+func (s *Server) HandleStatusPageWebhook(ctx *gin.Context) {
+	var body statusPageWebhook
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(400, errorJson("Failed to parse body"))
+		return
+	}
+
+	// Webhooks are only sent for pages we own, so only manage mode feeds can receive them. A page may be split across
+	// several feeds, each of which processes the incident.
+	var feeds []config.Feed
+	for _, f := range s.config.Feeds {
+		if f.Mode == config.StatusPageModeManage && f.PageId == body.Page.ID {
+			feeds = append(feeds, f)
+		}
+	}
+
+	if len(feeds) == 0 {
+		s.logger.Warn("Received webhook for unknown page", zap.String("page_id", body.Page.ID))
+		ctx.JSON(400, errorJson("Unknown page"))
+		return
+	}
+
+	switch {
+	case body.Incident != nil:
+		s.logger.Info("Received incident webhook", zap.String("page_id", body.Page.ID), zap.String("incident_id", body.Incident.ID), zap.String("status", body.Incident.Status))
+
+		// Statuspage expects a prompt response, so process the incident in the background. Polling acts as a
+		// reconciliation fallback should processing fail or be cut short.
+		incident := *body.Incident
+		go func() {
+			processCtx, cancel := context.WithTimeout(s.ctx, s.config.Daemon.ExecutionTimeout)
+			defer cancel()
+
+			for _, feed := range feeds {
+				s.processor.ProcessIncident(processCtx, feed.Name, incident)
+			}
+		}()
+	case body.Component != nil:
+		fields := []zap.Field{zap.String("component_id", body.Component.ID), zap.String("status", body.Component.Status)}
+		if body.ComponentUpdate != nil {
+			fields = append(fields, zap.String("old_status", body.ComponentUpdate.OldStatus), zap.String("new_status", body.ComponentUpdate.NewStatus))
+		}
+		s.logger.Info("Received component webhook", fields...)
+
+		// Component changes usually accompany incident changes, so poll straight away rather than waiting
+		s.processor.Trigger()
+	default:
+		ctx.JSON(400, errorJson("Unknown webhook type"))
+		return
+	}
+
+	ctx.Status(204)
+}
@@ -0,0 +1,100 @@
+// Package i18n translates the messages shown in Discord.
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// DefaultLocale is the locale messages are written in. Messages are keyed by their English text, which is used for
+// any message that has not been translated.
+const DefaultLocale = "en"
+
+var (
+	builder   = catalog.NewBuilder(catalog.Fallback(language.English))
+	supported = []language.Tag{language.English}
+	matcher   = language.NewMatcher(supported)
+	// plain holds the translations of each locale, for looking up messages that are not formatted
+	plain = make(map[language.Tag]map[string]string)
+)
+
+// Load reads every translation file in the given directory. Each file is named after its locale, such as de.json or
+// pt-BR.json, and holds a JSON object mapping the English text of each message to its translation.
+func Load(dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		tag, err := language.Parse(name)
+		if err != nil {
+			return fmt.Errorf("translation file %s: invalid locale: %w", path, err)
+		}
+
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		var messages map[string]string
+		if err := json.Unmarshal(contents, &messages); err != nil {
+			return fmt.Errorf("translation file %s: %w", path, err)
+		}
+
+		plain[tag] = messages
+		for key, translation := range messages {
+			if err := builder.SetString(tag, key, translation); err != nil {
+				return fmt.Errorf("translation file %s: %w", path, err)
+			}
+		}
+
+		supported = append(supported, tag)
+	}
+
+	matcher = language.NewMatcher(supported)
+	return nil
+}
+
+// Printer returns a printer for the closest locale to the given one that has translations, such as a Discord user's
+// locale. English is used if the locale is empty or no translations match.
+func Printer(locale string) *message.Printer {
+	return message.NewPrinter(match(locale), message.Catalog(builder))
+}
+
+// T translates the given message to the given locale, formatting it with args as with fmt.Sprintf. Without args, the
+// message is returned as written, so text such as a component name containing % is not mistaken for a verb.
+func T(locale, key string, args ...any) string {
+	if len(args) == 0 {
+		if translation, ok := plain[match(locale)][key]; ok {
+			return translation
+		}
+
+		return key
+	}
+
+	return Printer(locale).Sprintf(key, args...)
+}
+
+// match returns the closest locale to the given one that has translations, or English if there is none.
+func match(locale string) language.Tag {
+	if locale != "" {
+		if _, idx, confidence := matcher.Match(language.Make(locale)); confidence != language.No {
+			return supported[idx]
+		}
+	}
+
+	return language.English
+}
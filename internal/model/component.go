@@ -1,6 +1,18 @@
 package model
 
-import "time"
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TicketsBot-cloud/gdl/objects/interaction/component"
+	"github.com/TicketsBot-cloud/status-updates/internal/config"
+	"github.com/TicketsBot-cloud/status-updates/internal/i18n"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
 
 // Component represents the structure of a component
 type Component struct {
@@ -27,3 +39,218 @@ type AffectedComponent struct {
 	OldStatus string `json:"old_status"`
 	NewStatus string `json:"new_status"`
 }
+
+// StatusLabel returns the human readable label for a component status, translated to the given locale.
+func StatusLabel(locale, status string) string {
+	return i18n.T(locale, config.Conf.Severity(status).Label)
+}
+
+// statusNames holds the English names of incident and maintenance statuses
+var statusNames = map[string]string{
+	"investigating": "Investigating",
+	"identified":    "Identified",
+	"monitoring":    "Monitoring",
+	"resolved":      "Resolved",
+	"postmortem":    "Postmortem",
+	"scheduled":     "Scheduled",
+	"in_progress":   "In Progress",
+	"verifying":     "Verifying",
+	"completed":     "Completed",
+}
+
+// StatusName returns the human readable name of an incident or maintenance status, translated to the given locale.
+func StatusName(locale, status string) string {
+	name, ok := statusNames[status]
+	if !ok {
+		return cases.Title(language.English).String(strings.ReplaceAll(status, "_", " "))
+	}
+
+	return i18n.T(locale, name)
+}
+
+// StatusColor returns the accent color for a component status.
+func StatusColor(status string) int {
+	return config.Conf.Severity(status).ColorValue()
+}
+
+// StatusEmoji returns an emoji representing a component status, for use where colors cannot be shown.
+func StatusEmoji(status string) string {
+	return config.Conf.Severity(status).Emoji
+}
+
+// StatusRank orders component statuses by severity, from operational upwards, so the worst of several statuses can
+// be found.
+func StatusRank(status string) int {
+	switch status {
+	case "operational":
+		return 0
+	case "under_maintenance":
+		return 1
+	case "degraded_performance":
+		return 2
+	case "partial_outage":
+		return 3
+	case "major_outage":
+		return 4
+	default:
+		return 0
+	}
+}
+
+// GenerateStatusChangeContainer renders an announcement that the component changed from the previous status to its
+// current status.
+func (c Component) GenerateStatusChangeContainer(previous, locale string) component.Component {
+	color := StatusColor(c.Status)
+	return component.BuildContainer(component.Container{
+		Components: []component.Component{
+			component.BuildTextDisplay(component.TextDisplay{
+				Content: StatusEmoji(c.Status) + " " + i18n.T(locale, "**%s** changed from %s to %s", c.Name,
+					StatusLabel(locale, previous), StatusLabel(locale, c.Status)) + fmt.Sprintf("\n-# <t:%d:f>", time.Now().Unix()),
+			}),
+		},
+		AccentColor: &color,
+	})
+}
+
+// GenerateStatusContainer renders the current status of every component, grouped by component group, followed by
+// links to the given active incidents and maintenances. Incidents are also linked to their thread in threads, keyed by
+// incident ID, if present. The last updated time is omitted if updatedAt is zero. Text is translated to the given
+// locale.
+func GenerateStatusContainer(components []Component, activeIncidents []Incident, threads map[string]uint64, updatedAt time.Time, locale string) component.Component {
+	components = slices.Clone(components)
+	sort.SliceStable(components, func(a, b int) bool {
+		return components[a].Position < components[b].Position
+	})
+
+	worst := "operational"
+	groups := make(map[string][]Component)
+	var groupHeaders, ungrouped []Component
+	for _, c := range components {
+		if c.Group {
+			groupHeaders = append(groupHeaders, c)
+			continue
+		}
+
+		if c.OnlyShowIfDegraded && c.Status == "operational" {
+			continue
+		}
+
+		if StatusRank(c.Status) > StatusRank(worst) {
+			worst = c.Status
+		}
+
+		if c.GroupID == "" {
+			ungrouped = append(ungrouped, c)
+		} else {
+			groups[c.GroupID] = append(groups[c.GroupID], c)
+		}
+	}
+
+	summary := i18n.T(locale, "All Systems Operational")
+	if worst != "operational" {
+		summary = i18n.T(locale, "Some systems are experiencing issues")
+	}
+
+	heading := fmt.Sprintf("## %s %s", StatusEmoji(worst), summary)
+	children := []component.Component{
+		component.BuildTextDisplay(component.TextDisplay{
+			Content: heading,
+		}),
+		component.BuildSeparator(component.Separator{}),
+	}
+
+	sections := []componentSection{{lines: componentStatusLines(ungrouped, locale)}}
+	for _, group := range groupHeaders {
+		if members := groups[group.ID]; len(members) > 0 {
+			sections = append(sections, componentSection{heading: group.Name, lines: componentStatusLines(members, locale)})
+		}
+	}
+
+	var incidentLines, maintenanceLines []string
+	for _, incident := range activeIncidents {
+		line := fmt.Sprintf("- [%s](%s) - %s", incident.Name, incident.Shortlink, StatusName(locale, incident.Status))
+		if incident.IsMaintenance() && incident.Status == "scheduled" {
+			line += ", " + i18n.T(locale, "starts %s", fmt.Sprintf("<t:%d:R>", incident.ScheduledFor.Unix()))
+		}
+
+		if threadId := threads[incident.ID]; threadId != 0 {
+			line += fmt.Sprintf(" (<#%d>)", threadId)
+		}
+
+		if incident.IsMaintenance() {
+			maintenanceLines = append(maintenanceLines, line)
+		} else {
+			incidentLines = append(incidentLines, line)
+		}
+	}
+
+	var incidentsText, maintenanceText, updatedText string
+	if len(incidentLines) > 0 {
+		incidentsText = truncate("### "+i18n.T(locale, "Active Incidents")+"\n"+strings.Join(incidentLines, "\n"), activeListBudget)
+	}
+
+	if len(maintenanceLines) > 0 {
+		maintenanceText = truncate("### "+i18n.T(locale, "Maintenance")+"\n"+strings.Join(maintenanceLines, "\n"), activeListBudget)
+	}
+
+	if !updatedAt.IsZero() {
+		updatedText = "-# " + i18n.T(locale, "Last updated %s", fmt.Sprintf("<t:%d:R>", updatedAt.Unix()))
+	}
+
+	// Components take up whatever room the rest of the status leaves
+	for _, text := range fitComponentSections(sections, textBudget(heading, incidentsText, maintenanceText, updatedText), locale) {
+		children = append(children, component.BuildTextDisplay(component.TextDisplay{
+			Content: text,
+		}))
+	}
+
+	for _, text := range []string{incidentsText, maintenanceText} {
+		if text != "" {
+			children = append(children,
+				component.BuildSeparator(component.Separator{}),
+				component.BuildTextDisplay(component.TextDisplay{
+					Content: text,
+				}),
+			)
+		}
+	}
+
+	if updatedText != "" {
+		children = append(children, component.BuildTextDisplay(component.TextDisplay{
+			Content: updatedText,
+		}))
+	}
+
+	color := StatusColor(worst)
+	return component.BuildContainer(component.Container{
+		Components:  children,
+		AccentColor: &color,
+	})
+}
+
+// componentStatusLines returns a line for each component, showing its current status.
+func componentStatusLines(components []Component, locale string) []string {
+	lines := make([]string, len(components))
+	for idx, c := range components {
+		lines[idx] = fmt.Sprintf("%s %s - %s", StatusEmoji(c.Status), c.Name, StatusLabel(locale, c.Status))
+	}
+
+	return lines
+}
+
+// formatTransitions lists each affected component along with its change in status, or only its new status if the
+// status did not change. Components that do not fit within componentListBudget are collapsed into a single line.
+func formatTransitions(affected []AffectedComponent, locale string) string {
+	lines := make([]string, len(affected))
+	for idx, c := range affected {
+		if c.OldStatus == "" || c.OldStatus == c.NewStatus {
+			lines[idx] = fmt.Sprintf("%s %s - %s", StatusEmoji(c.NewStatus), c.Name, StatusLabel(locale, c.NewStatus))
+			continue
+		}
+
+		lines[idx] = fmt.Sprintf("%s %s - %s %s → %s %s", StatusEmoji(c.NewStatus), c.Name,
+			StatusEmoji(c.OldStatus), StatusLabel(locale, c.OldStatus), StatusEmoji(c.NewStatus), StatusLabel(locale, c.NewStatus))
+	}
+
+	return fitComponentLines(lines, componentListBudget, locale)
+}
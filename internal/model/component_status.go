@@ -0,0 +1,50 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/TicketsBot-cloud/status-updates/internal/db"
+)
+
+// ComponentStatus represents the last known status of a component, along with a status change that has been observed
+// but not yet announced
+type ComponentStatus struct {
+	Feed          string       `json:"feed" db:"feed"`
+	ComponentId   string       `json:"component_id" db:"component_id"`
+	Status        string       `json:"status" db:"status"`
+	PendingStatus string       `json:"pending_status" db:"pending_status"`
+	PendingSince  sql.NullTime `json:"pending_since" db:"pending_since"`
+	UpdatedAt     time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+func (s ComponentStatus) Save() error {
+	_, err := db.Client.NamedExec(`INSERT INTO component_statuses (feed, component_id, status, pending_status, pending_since, updated_at)
+		VALUES (:feed, :component_id, :status, :pending_status, :pending_since, :updated_at)
+		ON CONFLICT (feed, component_id) DO UPDATE SET status = EXCLUDED.status, pending_status = EXCLUDED.pending_status,
+		pending_since = EXCLUDED.pending_since, updated_at = EXCLUDED.updated_at`, s)
+
+	if err != nil {
+		fmt.Printf("Error saving component status: %v\n", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetComponentStatuses returns the last known status of every component of the given feed, keyed by component ID.
+func GetComponentStatuses(feed string) (map[string]ComponentStatus, error) {
+	var statuses []ComponentStatus
+	if err := db.Client.Select(&statuses, "SELECT * FROM component_statuses WHERE feed = $1", feed); err != nil {
+		fmt.Printf("Error retrieving component statuses: %v\n", err)
+		return nil, err
+	}
+
+	byId := make(map[string]ComponentStatus, len(statuses))
+	for _, status := range statuses {
+		byId[status.ComponentId] = status
+	}
+
+	return byId, nil
+}
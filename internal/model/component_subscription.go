@@ -0,0 +1,88 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TicketsBot-cloud/status-updates/internal/db"
+	"github.com/lib/pq"
+)
+
+// GetComponentSubscriptions returns the IDs of the components of the given feed a member follows.
+func GetComponentSubscriptions(guildId, userId uint64, feed string) ([]string, error) {
+	var componentIds []string
+	if err := db.Client.Select(&componentIds, "SELECT component_id FROM component_subscriptions WHERE guild_id = $1 AND user_id = $2 AND feed = $3",
+		guildId, userId, feed); err != nil {
+		fmt.Printf("Error retrieving component subscriptions: %v\n", err)
+		return nil, err
+	}
+
+	return componentIds, nil
+}
+
+// SetComponentSubscriptions sets which of the offered components of the given feed a member follows. Components that
+// were already followed keep the time the member started following them, and components that were not offered are
+// left untouched.
+func SetComponentSubscriptions(guildId, userId uint64, feed string, offered, selected []string) error {
+	// A nil slice is sent as NULL, which no component matches, so no subscriptions would be removed
+	if selected == nil {
+		selected = []string{}
+	}
+
+	tx, err := db.Client.Beginx()
+	if err != nil {
+		fmt.Printf("Error starting transaction: %v\n", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM component_subscriptions WHERE guild_id = $1 AND user_id = $2 AND feed = $3
+		AND component_id = ANY($4) AND NOT component_id = ANY($5)`,
+		guildId, userId, feed, pq.Array(offered), pq.Array(selected)); err != nil {
+		fmt.Printf("Error removing component subscriptions: %v\n", err)
+		return err
+	}
+
+	for _, componentId := range selected {
+		if _, err := tx.Exec(`INSERT INTO component_subscriptions (guild_id, user_id, feed, component_id) VALUES ($1, $2, $3, $4)
+			ON CONFLICT DO NOTHING`, guildId, userId, feed, componentId); err != nil {
+			fmt.Printf("Error saving component subscription: %v\n", err)
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetComponentSubscribers returns the IDs of the members of a guild who follow any of the given components of a feed.
+func GetComponentSubscribers(guildId uint64, feed string, componentIds []string) ([]uint64, error) {
+	var userIds []uint64
+	if err := db.Client.Select(&userIds, "SELECT DISTINCT user_id FROM component_subscriptions WHERE guild_id = $1 AND feed = $2 AND component_id = ANY($3)",
+		guildId, feed, pq.Array(componentIds)); err != nil {
+		fmt.Printf("Error retrieving component subscribers: %v\n", err)
+		return nil, err
+	}
+
+	return userIds, nil
+}
+
+// GetComponentFollowers returns the users in any guild who follow any of the given components of a feed, and when
+// they started doing so.
+func GetComponentFollowers(feed string, componentIds []string) (map[uint64]time.Time, error) {
+	var rows []struct {
+		UserId    uint64    `db:"user_id"`
+		CreatedAt time.Time `db:"created_at"`
+	}
+	if err := db.Client.Select(&rows, "SELECT user_id, MIN(created_at) AS created_at FROM component_subscriptions WHERE feed = $1 AND component_id = ANY($2) GROUP BY user_id",
+		feed, pq.Array(componentIds)); err != nil {
+		fmt.Printf("Error retrieving component followers: %v\n", err)
+		return nil, err
+	}
+
+	followers := make(map[uint64]time.Time, len(rows))
+	for _, row := range rows {
+		followers[row.UserId] = row.CreatedAt
+	}
+
+	return followers, nil
+}
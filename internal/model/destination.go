@@ -0,0 +1,150 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/TicketsBot-cloud/status-updates/internal/db"
+)
+
+// IncidentDestination represents the Discord resources an incident has been announced with in a single channel
+type IncidentDestination struct {
+	Feed       string        `json:"feed" db:"feed"`
+	PageId     string        `json:"page_id" db:"page_id"`
+	IncidentId string        `json:"incident_id" db:"incident_id"`
+	GuildId    uint64        `json:"guild_id" db:"guild_id"`
+	ChannelId  uint64        `json:"channel_id" db:"channel_id"`
+	MessageId  uint64        `json:"message_id" db:"message_id"`
+	ThreadId   uint64        `json:"thread_id" db:"thread_id"`
+	RoleId     uint64        `json:"role_id" db:"role_id"`
+	State      IncidentState `json:"state" db:"state"`
+	CreatedAt  time.Time     `json:"created_at" db:"created_at"`
+}
+
+func (d IncidentDestination) Save() error {
+	_, err := db.Client.NamedExec(`INSERT INTO incident_destinations (feed, page_id, incident_id, guild_id, channel_id, message_id, thread_id, role_id, state, created_at)
+		VALUES (:feed, :page_id, :incident_id, :guild_id, :channel_id, :message_id, :thread_id, :role_id, :state, :created_at)
+		ON CONFLICT (feed, page_id, incident_id, channel_id) DO UPDATE SET guild_id = EXCLUDED.guild_id, message_id = EXCLUDED.message_id,
+		thread_id = EXCLUDED.thread_id, role_id = EXCLUDED.role_id, state = EXCLUDED.state`, d)
+
+	if err != nil {
+		fmt.Printf("Error saving incident destination: %v\n", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeliveredUpdates returns the set of incident update IDs that have already been posted to the incident thread.
+func (d IncidentDestination) DeliveredUpdates() (map[string]bool, error) {
+	var updateIds []string
+	if err := db.Client.Select(&updateIds, "SELECT update_id FROM incident_updates WHERE incident_id = $1 AND channel_id = $2", d.IncidentId, d.ChannelId); err != nil {
+		fmt.Printf("Error retrieving delivered updates: %v\n", err)
+		return nil, err
+	}
+
+	delivered := make(map[string]bool, len(updateIds))
+	for _, id := range updateIds {
+		delivered[id] = true
+	}
+
+	return delivered, nil
+}
+
+// MarkUpdateDelivered records that the given incident update has been posted to the incident thread.
+func (d IncidentDestination) MarkUpdateDelivered(updateId string) error {
+	_, err := db.Client.Exec(`INSERT INTO incident_updates (incident_id, channel_id, update_id, delivered_at) VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (incident_id, channel_id, update_id) DO NOTHING`, d.IncidentId, d.ChannelId, updateId)
+	if err != nil {
+		fmt.Printf("Error marking update as delivered: %v\n", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetIncidentDestinations returns every channel the given incident has been announced in by the given feed.
+func GetIncidentDestinations(feed, pageId, incidentId string) ([]IncidentDestination, error) {
+	var destinations []IncidentDestination
+	if err := db.Client.Select(&destinations, "SELECT * FROM incident_destinations WHERE feed = $1 AND page_id = $2 AND incident_id = $3 ORDER BY created_at",
+		feed, pageId, incidentId); err != nil {
+		fmt.Printf("Error retrieving incident destinations: %v\n", err)
+		return nil, err
+	}
+
+	return destinations, nil
+}
+
+// PublishedUpdates returns the set of incident update IDs that were published to the status page from a message in
+// the incident thread, and so must not be posted to the thread again.
+func (d IncidentDestination) PublishedUpdates() (map[string]bool, error) {
+	var updateIds []string
+	if err := db.Client.Select(&updateIds, "SELECT update_id FROM published_updates WHERE incident_id = $1 AND channel_id = $2", d.IncidentId, d.ChannelId); err != nil {
+		fmt.Printf("Error retrieving published updates: %v\n", err)
+		return nil, err
+	}
+
+	published := make(map[string]bool, len(updateIds))
+	for _, id := range updateIds {
+		published[id] = true
+	}
+
+	return published, nil
+}
+
+// MarkUpdatePublished records that the given incident update was published to the status page from a message in the
+// incident thread.
+func (d IncidentDestination) MarkUpdatePublished(updateId string, messageId uint64) error {
+	_, err := db.Client.Exec(`INSERT INTO published_updates (incident_id, channel_id, update_id, message_id, published_at) VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (incident_id, channel_id, update_id) DO NOTHING`, d.IncidentId, d.ChannelId, updateId, messageId)
+	if err != nil {
+		fmt.Printf("Error marking update as published: %v\n", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetDestinationByThread returns the announcement whose incident thread is the given channel.
+func GetDestinationByThread(threadId uint64) (IncidentDestination, error) {
+	var destination IncidentDestination
+	if err := db.Client.Get(&destination, "SELECT * FROM incident_destinations WHERE thread_id = $1", threadId); err != nil {
+		if err == sql.ErrNoRows {
+			return IncidentDestination{}, fmt.Errorf("no incident thread with ID %d: %w", threadId, err)
+		}
+		fmt.Printf("Error retrieving incident destination: %v\n", err)
+		return IncidentDestination{}, err
+	}
+
+	return destination, nil
+}
+
+// SentNotices returns the set of notices, such as maintenance reminders, that have already been posted to the
+// incident thread.
+func (d IncidentDestination) SentNotices() (map[string]bool, error) {
+	var notices []string
+	if err := db.Client.Select(&notices, "SELECT notice FROM incident_notices WHERE incident_id = $1 AND channel_id = $2", d.IncidentId, d.ChannelId); err != nil {
+		fmt.Printf("Error retrieving sent notices: %v\n", err)
+		return nil, err
+	}
+
+	sent := make(map[string]bool, len(notices))
+	for _, notice := range notices {
+		sent[notice] = true
+	}
+
+	return sent, nil
+}
+
+// MarkNoticeSent records that the given notice has been posted to the incident thread.
+func (d IncidentDestination) MarkNoticeSent(notice string) error {
+	_, err := db.Client.Exec(`INSERT INTO incident_notices (incident_id, channel_id, notice, sent_at) VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (incident_id, channel_id, notice) DO NOTHING`, d.IncidentId, d.ChannelId, notice)
+	if err != nil {
+		fmt.Printf("Error marking notice as sent: %v\n", err)
+		return err
+	}
+
+	return nil
+}
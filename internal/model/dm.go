@@ -0,0 +1,149 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/TicketsBot-cloud/status-updates/internal/db"
+	"github.com/lib/pq"
+)
+
+// DmPreferences represents whether a user receives incident updates by direct message
+type DmPreferences struct {
+	UserId    uint64 `json:"user_id" db:"user_id"`
+	Enabled   bool   `json:"enabled" db:"enabled"`
+	ChannelId uint64 `json:"channel_id" db:"channel_id"` // ChannelId is the DM channel with the user, once opened
+	// Failures is the number of consecutive failed deliveries
+	Failures int `json:"failures" db:"failures"`
+	// Locale is the user's Discord locale when they last changed their preferences
+	Locale    string    `json:"locale" db:"locale"`
+	EnabledAt time.Time `json:"enabled_at" db:"enabled_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+func (p DmPreferences) Save() error {
+	_, err := db.Client.NamedExec(`INSERT INTO dm_preferences (user_id, enabled, channel_id, failures, locale, enabled_at, updated_at)
+		VALUES (:user_id, :enabled, :channel_id, :failures, :locale, :enabled_at, :updated_at)
+		ON CONFLICT (user_id) DO UPDATE SET enabled = EXCLUDED.enabled, channel_id = EXCLUDED.channel_id,
+		failures = EXCLUDED.failures, locale = EXCLUDED.locale, enabled_at = EXCLUDED.enabled_at, updated_at = EXCLUDED.updated_at`, p)
+
+	if err != nil {
+		fmt.Printf("Error saving DM preferences: %v\n", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetDeliveredDmUpdates returns, for each of the given users, the set of updates of the given incident that have
+// already been sent to them.
+func GetDeliveredDmUpdates(incidentId string, userIds []uint64) (map[uint64]map[string]bool, error) {
+	ids := make([]int64, len(userIds))
+	for i, id := range userIds {
+		ids[i] = int64(id)
+	}
+
+	var rows []struct {
+		UserId   uint64 `db:"user_id"`
+		UpdateId string `db:"update_id"`
+	}
+	if err := db.Client.Select(&rows, "SELECT user_id, update_id FROM dm_deliveries WHERE incident_id = $1 AND user_id = ANY($2)",
+		incidentId, pq.Array(ids)); err != nil {
+		fmt.Printf("Error retrieving delivered DMs: %v\n", err)
+		return nil, err
+	}
+
+	delivered := make(map[uint64]map[string]bool, len(userIds))
+	for _, row := range rows {
+		if delivered[row.UserId] == nil {
+			delivered[row.UserId] = make(map[string]bool)
+		}
+		delivered[row.UserId][row.UpdateId] = true
+	}
+
+	return delivered, nil
+}
+
+// MarkUpdateDelivered records that the given incident update has been sent to the user.
+func (p DmPreferences) MarkUpdateDelivered(incidentId, updateId string) error {
+	_, err := db.Client.Exec(`INSERT INTO dm_deliveries (user_id, incident_id, update_id, delivered_at) VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id, incident_id, update_id) DO NOTHING`, p.UserId, incidentId, updateId)
+	if err != nil {
+		fmt.Printf("Error marking DM as delivered: %v\n", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetDmPreferences returns the DM preferences of a user, or disabled preferences if they have never opted in.
+func GetDmPreferences(userId uint64) (DmPreferences, error) {
+	var prefs DmPreferences
+	if err := db.Client.Get(&prefs, "SELECT * FROM dm_preferences WHERE user_id = $1", userId); err != nil {
+		if err == sql.ErrNoRows {
+			return DmPreferences{UserId: userId}, nil
+		}
+		fmt.Printf("Error retrieving DM preferences: %v\n", err)
+		return DmPreferences{}, err
+	}
+
+	return prefs, nil
+}
+
+// GetEnabledDmPreferences returns the preferences of those of the given users who receive updates by direct message.
+func GetEnabledDmPreferences(userIds []uint64) ([]DmPreferences, error) {
+	ids := make([]int64, len(userIds))
+	for i, id := range userIds {
+		ids[i] = int64(id)
+	}
+
+	var prefs []DmPreferences
+	if err := db.Client.Select(&prefs, "SELECT * FROM dm_preferences WHERE enabled AND user_id = ANY($1)", pq.Array(ids)); err != nil {
+		fmt.Printf("Error retrieving DM preferences: %v\n", err)
+		return nil, err
+	}
+
+	return prefs, nil
+}
+
+// FollowIncident records that a user opted into updates for an incident.
+func FollowIncident(incidentId string, userId uint64) error {
+	_, err := db.Client.Exec(`INSERT INTO incident_followers (incident_id, user_id) VALUES ($1, $2)
+		ON CONFLICT (incident_id, user_id) DO NOTHING`, incidentId, userId)
+	if err != nil {
+		fmt.Printf("Error following incident: %v\n", err)
+		return err
+	}
+
+	return nil
+}
+
+// UnfollowIncident records that a user opted out of updates for an incident.
+func UnfollowIncident(incidentId string, userId uint64) error {
+	if _, err := db.Client.Exec("DELETE FROM incident_followers WHERE incident_id = $1 AND user_id = $2", incidentId, userId); err != nil {
+		fmt.Printf("Error unfollowing incident: %v\n", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetIncidentFollowers returns the users who opted into updates for an incident, and when they did so.
+func GetIncidentFollowers(incidentId string) (map[uint64]time.Time, error) {
+	var rows []struct {
+		UserId    uint64    `db:"user_id"`
+		CreatedAt time.Time `db:"created_at"`
+	}
+	if err := db.Client.Select(&rows, "SELECT user_id, created_at FROM incident_followers WHERE incident_id = $1", incidentId); err != nil {
+		fmt.Printf("Error retrieving incident followers: %v\n", err)
+		return nil, err
+	}
+
+	followers := make(map[uint64]time.Time, len(rows))
+	for _, row := range rows {
+		followers[row.UserId] = row.CreatedAt
+	}
+
+	return followers, nil
+}
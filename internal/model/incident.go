@@ -3,12 +3,15 @@ package model
 import (
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/TicketsBot-cloud/gdl/objects/interaction/component"
 	"github.com/TicketsBot-cloud/status-updates/internal/db"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
+	"github.com/TicketsBot-cloud/status-updates/internal/i18n"
+	"github.com/TicketsBot-cloud/status-updates/internal/render"
+	"github.com/TicketsBot-cloud/status-updates/internal/utils"
 )
 
 // Incident represents the structure of the given JSON object
@@ -46,6 +49,23 @@ type Incident struct {
 	Status                                    string           `json:"status"`
 	UpdatedAt                                 time.Time        `json:"updated_at"`
 	ReminderIntervals                         string           `json:"reminder_intervals"` // JSON string representation of intervals
+
+	// Source is the name of the source the incident was retrieved from
+	Source string `json:"-"`
+	// Locale is the locale the incident is rendered in
+	Locale string `json:"-"`
+}
+
+// IncidentRequest represents the fields of an incident that can be set when creating or updating it. Empty fields
+// are left unchanged.
+type IncidentRequest struct {
+	Name           string   `json:"name,omitempty"`
+	Status         string   `json:"status,omitempty"`
+	ImpactOverride string   `json:"impact_override,omitempty"`
+	Body           string   `json:"body,omitempty"`
+	ComponentIds   []string `json:"component_ids,omitempty"`
+	// Components sets the status of affected components, keyed by component ID
+	Components map[string]string `json:"components,omitempty"`
 }
 
 // IncidentUpdate represents the structure of an incident update
@@ -66,118 +86,168 @@ type IncidentUpdate struct {
 }
 
 func (iu IncidentUpdate) AsString() string {
-	return fmt.Sprintf("[<t:%d:t>] %s", iu.DisplayAt.Unix(), iu.Body)
+	return fmt.Sprintf("[<t:%d:t>] %s", iu.DisplayAt.Unix(), render.Markdown(iu.Body))
 }
 
+// GenerateContainer renders the incident announcement using the announcement template.
 func (i Incident) GenerateContainer() component.Component {
-	statusCaser := cases.Title(language.English)
 	color := i.GetColor()
-	var msgFormat string
-	for _, update := range i.IncidentUpdates {
-		msgFormat += fmt.Sprintf("%s\n\n", update.AsString())
+	data := i.templateData()
+
+	// Render without the updates first, to find how much room is left for them
+	fixed := execute(templates.announcement, defaultAnnouncementTemplate, data)
+	data.Updates = i.formatUpdates(bodyBudget(fixed))
+
+	components := textSections(execute(templates.announcement, defaultAnnouncementTemplate, data))
+	return component.BuildContainer(component.Container{
+		Components:  append(components, component.BuildActionRow(i.buttons()...)),
+		AccentColor: &color,
+	})
+}
+
+// MentionLine renders the line posted above an announcement or update for the given event, mentioning the given
+// role if set.
+func (i Incident) MentionLine(event string, roleId uint64) string {
+	data := i.templateData()
+	data.Event = event
+	if roleId != 0 {
+		data.Mention = fmt.Sprintf("<@&%d>", roleId)
 	}
+
+	return truncate(strings.TrimSpace(execute(templates.mention, defaultMentionTemplate, data)), mentionReserve)
+}
+
+// GenerateResolutionContainer renders the message posted to the incident thread once the incident is resolved.
+func (i Incident) GenerateResolutionContainer() component.Component {
+	color := i.GetColor()
+	return component.BuildContainer(component.Container{
+		Components:  textSections(execute(templates.resolution, defaultResolutionTemplate, i.templateData())),
+		AccentColor: &color,
+	})
+}
+
+// IsResolvedStatus reports whether an incident or maintenance with the given status has finished. Statuspage moves a
+// resolved incident to postmortem once its postmortem is published.
+func IsResolvedStatus(status string) bool {
+	return status == "resolved" || status == "completed" || status == "postmortem"
+}
+
+// buttons returns the buttons shown below the incident announcement.
+func (i Incident) buttons() []component.Component {
 	buttons := []component.Component{component.BuildButton(component.Button{
-		Label: "Status Page",
+		Label: i18n.T(i.Locale, "Status Page"),
 		Style: component.ButtonStyleLink,
 		Url:   &i.Shortlink,
 	})}
-	if i.Status != "resolved" && i.Status != "completed" {
+	if !IsResolvedStatus(i.Status) {
 		buttons = append(buttons, component.BuildButton(component.Button{
-			Label:    "Receive Updates",
+			Label:    i18n.T(i.Locale, "Receive Updates"),
 			Style:    component.ButtonStyleSecondary,
 			CustomId: fmt.Sprintf("incident-role-%s", i.ID),
+		}), component.BuildButton(component.Button{
+			Label:    i18n.T(i.Locale, "Stop Updates"),
+			Style:    component.ButtonStyleSecondary,
+			CustomId: fmt.Sprintf("incident-unsubscribe-%s", i.ID),
 		}))
 	}
-	return component.BuildContainer(component.Container{
-		Components: []component.Component{
-			component.BuildTextDisplay(component.TextDisplay{
-				Content: fmt.Sprintf("## %s - %s", i.GetSeverity(), i.Name),
-			}),
-			component.BuildSeparator(component.Separator{}),
-			component.BuildTextDisplay(component.TextDisplay{
-				Content: msgFormat,
-			}),
-			component.BuildTextDisplay(component.TextDisplay{
-				Content: "Status: " + statusCaser.String(i.Status),
-			}),
-			component.BuildActionRow(buttons...),
-		},
-		AccentColor: &color,
-	})
+
+	return buttons
 }
 
-func (i Incident) GetSeverity() string {
-	severity := ""
-	if len(i.Components) == 0 {
-		return "Unknown"
+// impactSeverities maps incident impacts to the component status of equivalent severity
+var impactSeverities = map[string]string{
+	"minor":       "degraded_performance",
+	"major":       "partial_outage",
+	"critical":    "major_outage",
+	"maintenance": "under_maintenance",
+}
+
+// Severity returns the component status that best describes the severity of the incident. An impact override takes
+// precedence, followed by the worst of the incident's impact and the statuses of every affected component. An empty
+// string is returned if the severity is unknown.
+func (i Incident) Severity() string {
+	if severity, ok := impactSeverities[i.ImpactOverride]; ok {
+		return severity
 	}
-	switch i.Components[0].Status {
-	case "partial_outage":
-		severity = "Partial Outage"
-	case "major_outage":
-		severity = "Major Outage"
-	case "degraded_performance":
-		severity = "Degraded Performance"
-	case "operational":
-		severity = "Operational"
-	default:
-		severity = "Unknown"
+
+	if i.IsMaintenance() {
+		return "under_maintenance"
+	}
+
+	worst := impactSeverities[i.Impact]
+	for _, c := range i.Components {
+		if worst == "" || StatusRank(c.Status) > StatusRank(worst) {
+			worst = c.Status
+		}
 	}
 
-	return severity
+	return worst
+}
+
+func (i Incident) GetSeverity() string {
+	return StatusLabel(i.Locale, i.Severity())
 }
 
 func (i Incident) GetColor() int {
-	color := 0x00CD00
-	if len(i.Components) == 0 {
-		return color
+	return StatusColor(i.Severity())
+}
+
+// GenerateUpdateContainers renders an incident update to be posted to the incident thread using the update template.
+// Updates too long for a single message are split across several containers, each of which must be sent as a
+// separate message.
+func (i Incident) GenerateUpdateContainers(u IncidentUpdate) []component.Component {
+	color := i.GetColor()
+	data := i.templateData()
+	data.Update = u
+	data.Transitions = formatTransitions(u.AffectedComponents, i.Locale)
+
+	// Render without the body first, to find how much room is left for it
+	fixed := execute(templates.update, defaultUpdateTemplate, data)
+	chunks := utils.SplitMessage(render.Markdown(u.Body), bodyBudget(fixed))
+	if len(chunks) == 0 {
+		chunks = []string{""}
 	}
-	switch i.Components[0].Status {
-	case "partial_outage":
-		color = 0xFFA500
-	case "major_outage":
-		color = 0xFF0000
-	case "degraded_performance":
-		color = 0xFFA500
-	case "operational":
-		color = 0x00CD00
-	default:
-		color = 0x00CD00
+
+	containers := make([]component.Component, len(chunks))
+	for idx, chunk := range chunks {
+		components := []component.Component{component.BuildTextDisplay(component.TextDisplay{
+			Content: chunk,
+		})}
+		if idx == 0 {
+			data.Body = chunk
+			components = textSections(execute(templates.update, defaultUpdateTemplate, data))
+		}
+
+		containers[idx] = component.BuildContainer(component.Container{
+			Components:  components,
+			AccentColor: &color,
+		})
 	}
 
-	return color
+	return containers
 }
 
-func (i Incident) GenerateUpdateContainer(u IncidentUpdate) component.Component {
-	color := i.GetColor()
-	return component.BuildContainer(component.Container{
-		Components: []component.Component{
-			component.BuildTextDisplay(component.TextDisplay{
-				Content: fmt.Sprintf("## %s - %s", i.GetSeverity(), i.Name),
-			}),
-			component.BuildSeparator(component.Separator{}),
-			component.BuildTextDisplay(component.TextDisplay{
-				Content: fmt.Sprintf("[<t:%d:t>] %s", u.DisplayAt.Unix(), u.Body),
-			}),
-			component.BuildTextDisplay(component.TextDisplay{
-				Content: "Status: " + cases.Title(language.English).String(u.Status),
-			}),
-		},
-		AccentColor: &color,
+// OrderUpdates sorts the incident updates chronologically, oldest first.
+func (i *Incident) OrderUpdates() {
+	sort.SliceStable(i.IncidentUpdates, func(a, b int) bool {
+		return i.IncidentUpdates[a].DisplayAt.Before(i.IncidentUpdates[b].DisplayAt)
 	})
 }
 
-func (i *Incident) OrderUpdates() {
-	newIncidentUpdates := make([]IncidentUpdate, len(i.IncidentUpdates))
-	for j, k := len(i.IncidentUpdates)-1, 0; j >= 0; j, k = j-1, k+1 {
-		newIncidentUpdates[k] = i.IncidentUpdates[j]
+// ComponentIds returns the IDs of the components affected by the incident.
+func (i Incident) ComponentIds() []string {
+	ids := make([]string, len(i.Components))
+	for idx, c := range i.Components {
+		ids[idx] = c.ID
 	}
-	i.IncidentUpdates = newIncidentUpdates
+
+	return ids
 }
 
+// Exists reports whether the incident is tracked by its feed.
 func (i Incident) Exists() (bool, error) {
 	var exists bool
-	if err := db.Client.Get(&exists, "SELECT EXISTS(SELECT 1 FROM incidents WHERE id = $1)", i.ID); err != nil {
+	if err := db.Client.Get(&exists, "SELECT EXISTS(SELECT 1 FROM incidents WHERE source = $1 AND page_id = $2 AND id = $3)", i.Source, i.PageID, i.ID); err != nil {
 		fmt.Printf("Error checking if incident exists: %v\n", err)
 		return false, err
 	}
@@ -185,9 +255,11 @@ func (i Incident) Exists() (bool, error) {
 	return exists, nil
 }
 
+// Get returns the state of the incident tracked by its feed.
 func (i Incident) Get() (IncidentInfo, error) {
 	var info IncidentInfo
-	err := db.Client.Get(&info, "SELECT * FROM incidents WHERE id = $1", i.ID)
+	err := db.Client.Get(&info, "SELECT id, created_at, updated_at, status, source, page_id FROM incidents WHERE source = $1 AND page_id = $2 AND id = $3",
+		i.Source, i.PageID, i.ID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return IncidentInfo{}, fmt.Errorf("incident with ID %s not found", i.ID)
@@ -198,3 +270,23 @@ func (i Incident) Get() (IncidentInfo, error) {
 
 	return info, nil
 }
+
+// AssignPage records the page of the incident on any of its rows tracked by its feed before incidents were keyed by
+// page, so that they continue to be found.
+func (i Incident) AssignPage() error {
+	if i.PageID == "" {
+		return nil
+	}
+
+	if _, err := db.Client.Exec(`UPDATE incidents SET page_id = $1 WHERE source = $2 AND page_id = '' AND id = $3`, i.PageID, i.Source, i.ID); err != nil {
+		fmt.Printf("Error assigning page to incident: %v\n", err)
+		return err
+	}
+
+	if _, err := db.Client.Exec(`UPDATE incident_destinations SET page_id = $1 WHERE feed = $2 AND page_id = '' AND incident_id = $3`, i.PageID, i.Source, i.ID); err != nil {
+		fmt.Printf("Error assigning page to incident destinations: %v\n", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package model
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/TicketsBot-cloud/status-updates/internal/i18n"
+)
+
+const (
+	// MessageTextLimit is the most characters of text Discord allows across every component of a message
+	MessageTextLimit = 4000
+	// mentionReserve is left out of the text budget of a container for the line posted above it, such as a role
+	// mention
+	mentionReserve = 200
+	// maxShownUpdates is the most updates shown in full in an incident announcement
+	maxShownUpdates = 10
+	// minBodyBudget is the least room left for the body of a container, however long the rest of it is. The container
+	// is truncated instead if the rest does not leave this much room.
+	minBodyBudget = 1000
+	// componentListBudget is the most characters used to list the components affected by an incident or update
+	componentListBudget = 1000
+	// activeListBudget is the most characters used to list active incidents, or maintenances, in a status container
+	activeListBudget = 1000
+)
+
+// componentSection is a list of component lines shown together, under the heading of their group if they have one
+type componentSection struct {
+	heading string
+	lines   []string
+}
+
+// formatUpdates renders as many of the newest updates as fit within budget characters, up to maxShownUpdates, oldest
+// first. Older updates are collapsed into a single line pointing to the status page. The newest update is always
+// shown, truncated if it does not fit on its own.
+func (i Incident) formatUpdates(budget int) string {
+	var shown []string
+	used := 0
+	for idx := len(i.IncidentUpdates) - 1; idx >= 0 && len(shown) < maxShownUpdates; idx-- {
+		text := i.IncidentUpdates[idx].AsString()
+
+		// Leave room for the line summarising the updates older than this one
+		reserve := 0
+		if idx > 0 {
+			reserve = textLength(i.collapsedLine(idx+1)) + 2
+		}
+
+		available := budget - used - reserve
+		if textLength(text)+2 > available {
+			if len(shown) == 0 {
+				shown = append(shown, truncate(text, available-2))
+			}
+			break
+		}
+
+		shown = append(shown, text)
+		used += textLength(text) + 2
+	}
+
+	lines := make([]string, 0, len(shown)+1)
+	if collapsed := len(i.IncidentUpdates) - len(shown); collapsed > 0 {
+		lines = append(lines, i.collapsedLine(collapsed))
+	}
+
+	for idx := len(shown) - 1; idx >= 0; idx-- {
+		lines = append(lines, shown[idx])
+	}
+
+	return strings.Join(lines, "\n\n")
+}
+
+// collapsedLine summarises the given number of updates that are not shown.
+func (i Incident) collapsedLine(count int) string {
+	if i.Shortlink == "" {
+		if count == 1 {
+			return "-# " + i18n.T(i.Locale, "1 earlier update, see the status page")
+		}
+		return "-# " + i18n.T(i.Locale, "%d earlier updates, see the status page", count)
+	}
+
+	if count == 1 {
+		return "-# " + i18n.T(i.Locale, "1 earlier update, see the [status page](%s)", i.Shortlink)
+	}
+	return "-# " + i18n.T(i.Locale, "%d earlier updates, see the [status page](%s)", count, i.Shortlink)
+}
+
+// fitComponentLines joins as many of the component lines as fit within budget characters. The remaining components
+// are collapsed into a single line pointing to the status page.
+func fitComponentLines(lines []string, budget int, locale string) string {
+	shown, used := 0, 0
+	for shown < len(lines) {
+		length := textLength(lines[shown]) + 1
+
+		// Leave room for the line summarising the components after this one
+		reserve := 0
+		if shown < len(lines)-1 {
+			reserve = textLength(moreComponentsLine(locale, len(lines)-shown-1)) + 1
+		}
+
+		if used+length+reserve > budget {
+			break
+		}
+
+		used += length
+		shown++
+	}
+
+	if shown == len(lines) {
+		return strings.Join(lines, "\n")
+	}
+
+	return strings.Join(append(lines[:shown:shown], moreComponentsLine(locale, len(lines)-shown)), "\n")
+}
+
+// fitComponentSections renders as many component lines as fit within budget characters, in order, as the text of
+// each section. The components that do not fit are collapsed into a single line pointing to the status page.
+func fitComponentSections(sections []componentSection, budget int, locale string) []string {
+	remaining := 0
+	for _, section := range sections {
+		remaining += len(section.lines)
+	}
+
+	var texts []string
+	used := 0
+	for _, section := range sections {
+		shown := 0
+		for _, line := range section.lines {
+			length := textLength(line) + 1
+			if shown == 0 && section.heading != "" {
+				length += textLength("### "+section.heading) + 1
+			}
+
+			// Leave room for the line summarising the components after this one
+			reserve := 0
+			if remaining > 1 {
+				reserve = textLength(moreComponentsLine(locale, remaining-1)) + 1
+			}
+
+			if used+length+reserve > budget {
+				break
+			}
+
+			used += length
+			remaining--
+			shown++
+		}
+
+		if shown > 0 {
+			text := strings.Join(section.lines[:shown], "\n")
+			if section.heading != "" {
+				text = "### " + section.heading + "\n" + text
+			}
+			texts = append(texts, text)
+		}
+
+		if shown < len(section.lines) {
+			break
+		}
+	}
+
+	if remaining > 0 {
+		texts = append(texts, moreComponentsLine(locale, remaining))
+	}
+
+	return texts
+}
+
+// moreComponentsLine summarises the given number of components that are not shown.
+func moreComponentsLine(locale string, count int) string {
+	if count == 1 {
+		return "-# " + i18n.T(locale, "1 more component, see the status page")
+	}
+	return "-# " + i18n.T(locale, "%d more components, see the status page", count)
+}
+
+// textBudget returns how many characters remain for the body of a container once the given text is accounted for.
+func textBudget(used ...string) int {
+	budget := MessageTextLimit - mentionReserve
+	for _, text := range used {
+		budget -= textLength(text)
+	}
+
+	return budget
+}
+
+// bodyBudget returns how many characters remain for the body of a container once the given text is accounted for,
+// but never less than minBodyBudget.
+func bodyBudget(used ...string) int {
+	return max(textBudget(used...), minBodyBudget)
+}
+
+func textLength(text string) int {
+	return utf8.RuneCountInString(text)
+}
+
+// truncate shortens text to at most limit characters, marking where it was cut.
+func truncate(text string, limit int) string {
+	if textLength(text) <= limit {
+		return text
+	}
+
+	if limit <= 1 {
+		return "…"
+	}
+
+	return string([]rune(text)[:limit-1]) + "…"
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/TicketsBot-cloud/gdl/objects/interaction/component"
+	"github.com/TicketsBot-cloud/status-updates/internal/i18n"
+)
+
+// DefaultReminderHours is how long before a maintenance window starts a reminder is posted, when the maintenance asks
+// for a reminder but does not specify any intervals.
+const DefaultReminderHours = 1
+
+// IsMaintenance reports whether the incident is a scheduled maintenance rather than an unplanned incident.
+func (i Incident) IsMaintenance() bool {
+	return !i.ScheduledFor.IsZero()
+}
+
+// ReminderHours returns how many hours before the start of the maintenance window reminders should be posted, largest
+// first. No reminders are posted unless the maintenance was scheduled with reminders enabled.
+func (i Incident) ReminderHours() []int {
+	if !i.IsMaintenance() || !i.ScheduledRemindPrior {
+		return nil
+	}
+
+	var hours []int
+	if i.ReminderIntervals != "" {
+		if err := json.Unmarshal([]byte(i.ReminderIntervals), &hours); err != nil {
+			fmt.Printf("Error parsing reminder intervals for incident %s: %v\n", i.ID, err)
+			hours = nil
+		}
+	}
+
+	if len(hours) == 0 {
+		return []int{DefaultReminderHours}
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(hours)))
+	return hours
+}
+
+// GenerateMaintenanceNoticeContainer renders a message posted to the incident thread when the maintenance is about to
+// start, or has automatically started or completed.
+func (i Incident) GenerateMaintenanceNoticeContainer(emoji, title string) component.Component {
+	color := maintenanceColor()
+	return component.BuildContainer(component.Container{
+		Components: []component.Component{
+			component.BuildTextDisplay(component.TextDisplay{
+				Content: fmt.Sprintf("## %s %s - %s", emoji, i18n.T(i.Locale, title), i.Name),
+			}),
+			component.BuildSeparator(component.Separator{}),
+			component.BuildTextDisplay(component.TextDisplay{
+				Content: i.countdown(),
+			}),
+		},
+		AccentColor: &color,
+	})
+}
+
+// countdown describes when the maintenance starts or ends, as a Discord relative timestamp.
+func (i Incident) countdown() string {
+	switch i.Status {
+	case "scheduled":
+		return i18n.T(i.Locale, "Starts %s", fmt.Sprintf("<t:%d:R>", i.ScheduledFor.Unix()))
+	case "in_progress", "verifying":
+		if i.ScheduledUntil.IsZero() {
+			return i18n.T(i.Locale, "Started %s", fmt.Sprintf("<t:%d:R>", i.ScheduledFor.Unix()))
+		}
+		return i18n.T(i.Locale, "Ends %s", fmt.Sprintf("<t:%d:R>", i.ScheduledUntil.Unix()))
+	default:
+		if i.ResolvedAt.IsZero() {
+			return i18n.T(i.Locale, "Completed")
+		}
+		return i18n.T(i.Locale, "Completed %s", fmt.Sprintf("<t:%d:R>", i.ResolvedAt.Unix()))
+	}
+}
+
+func maintenanceColor() int {
+	return StatusColor("under_maintenance")
+}
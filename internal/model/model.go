@@ -8,22 +8,37 @@ import (
 	"github.com/TicketsBot-cloud/status-updates/internal/db"
 )
 
-// IncidentInfo represents the state of a Discord message for an incident
+// IncidentState records how far the Discord resources for an incident destination have been provisioned.
+type IncidentState string
+
+const (
+	IncidentStateNew           IncidentState = ""               // Nothing has been posted yet
+	IncidentStateMessagePosted IncidentState = "message_posted" // The announcement message has been posted
+	IncidentStateRoleCreated   IncidentState = "role_created"   // The incident updates role has been created
+	IncidentStateThreadCreated IncidentState = "thread_created" // The incident thread has been started
+	IncidentStateReady         IncidentState = "ready"          // Provisioning is complete
+)
+
+// IncidentStatusMissing is recorded as the status of a tracked incident that its source no longer returns, such as
+// one that has been deleted, so that it is no longer polled for.
+const IncidentStatusMissing = "missing"
+
+// IncidentInfo represents the state of an incident we are tracking, keyed by the feed tracking it, its page and its
+// ID. The Discord resources it has been announced with are tracked per destination by IncidentDestination.
 type IncidentInfo struct {
 	Id            string    `json:"id" db:"id"`
-	RoleId        uint64    `json:"role_id" db:"role_id"`
-	MessageId     uint64    `json:"message_id" db:"message_id"`
-	ThreadId      uint64    `json:"thread_id" db:"thread_id"`
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
 	CurrentStatus string    `json:"status" db:"status"`
+	Source        string    `json:"source" db:"source"`
+	PageId        string    `json:"page_id" db:"page_id"`
 }
 
 func (i IncidentInfo) Save() error {
-	_, err := db.Client.NamedExec(`INSERT INTO incidents (id, role_id, message_id, thread_id, created_at, updated_at, status)
-		VALUES (:id, :role_id, :message_id, :thread_id, :created_at, :updated_at, :status)
-		ON CONFLICT (id) DO UPDATE SET role_id = EXCLUDED.role_id, message_id = EXCLUDED.message_id, thread_id = EXCLUDED.thread_id,
-		created_at = EXCLUDED.created_at, updated_at = EXCLUDED.updated_at, status = EXCLUDED.status`, i)
+	_, err := db.Client.NamedExec(`INSERT INTO incidents (id, created_at, updated_at, status, source, page_id)
+		VALUES (:id, :created_at, :updated_at, :status, :source, :page_id)
+		ON CONFLICT (source, page_id, id) DO UPDATE SET created_at = EXCLUDED.created_at, updated_at = EXCLUDED.updated_at,
+		status = EXCLUDED.status`, i)
 
 	if err != nil {
 		fmt.Printf("Error saving incident: %v\n", err)
@@ -32,3 +47,39 @@ func (i IncidentInfo) Save() error {
 
 	return nil
 }
+
+// GetIncidentIds returns the IDs of every incident from the given source that has been announced.
+func GetIncidentIds(source string) ([]string, error) {
+	var ids []string
+	if err := db.Client.Select(&ids, "SELECT id FROM incidents WHERE source = $1", source); err != nil {
+		fmt.Printf("Error retrieving incident IDs: %v\n", err)
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// GetUnresolvedIncidentIds returns the IDs of every announced incident from the given source that has not yet been
+// resolved or completed, or had a postmortem published, and is still returned by the source.
+func GetUnresolvedIncidentIds(source string) ([]string, error) {
+	var ids []string
+	if err := db.Client.Select(&ids, "SELECT id FROM incidents WHERE source = $1 AND status NOT IN ('resolved', 'completed', 'postmortem', $2)",
+		source, IncidentStatusMissing); err != nil {
+		fmt.Printf("Error retrieving unresolved incident IDs: %v\n", err)
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// MarkIncidentMissing records that the source no longer returns the given incident, so that it is no longer polled as
+// unresolved.
+func MarkIncidentMissing(source, incidentId string) error {
+	if _, err := db.Client.Exec("UPDATE incidents SET status = $1, updated_at = NOW() WHERE source = $2 AND id = $3",
+		IncidentStatusMissing, source, incidentId); err != nil {
+		fmt.Printf("Error marking incident as missing: %v\n", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/TicketsBot-cloud/gdl/objects/interaction/component"
+	"github.com/TicketsBot-cloud/status-updates/internal/i18n"
+	"github.com/TicketsBot-cloud/status-updates/internal/render"
+	"github.com/TicketsBot-cloud/status-updates/internal/utils"
+)
+
+// HasPostmortem reports whether a postmortem has been published for the incident.
+func (i Incident) HasPostmortem() bool {
+	return !i.PostmortemPublishedAt.IsZero() && !i.PostmortemIgnored && i.PostmortemBody != ""
+}
+
+// GeneratePostmortemContainers renders the postmortem of the incident, split across as many containers as needed to
+// fit within Discord's message length limits. Each container must be sent as a separate message.
+func (i Incident) GeneratePostmortemContainers() []component.Component {
+	color := i.GetColor()
+	heading := fmt.Sprintf("## 📝 %s - %s\n-# %s", i18n.T(i.Locale, "Postmortem"), i.Name,
+		i18n.T(i.Locale, "Published %s", fmt.Sprintf("<t:%d:f>", i.PostmortemPublishedAt.Unix())))
+	chunks := utils.SplitMessage(render.Markdown(i.PostmortemBody), bodyBudget(heading))
+
+	containers := make([]component.Component, len(chunks))
+	for idx, chunk := range chunks {
+		var components []component.Component
+		if idx == 0 {
+			components = append(components,
+				component.BuildTextDisplay(component.TextDisplay{
+					Content: heading,
+				}),
+				component.BuildSeparator(component.Separator{}),
+			)
+		}
+
+		containers[idx] = component.BuildContainer(component.Container{
+			Components: append(components, component.BuildTextDisplay(component.TextDisplay{
+				Content: chunk,
+			})),
+			AccentColor: &color,
+		})
+	}
+
+	return containers
+}
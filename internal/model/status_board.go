@@ -0,0 +1,47 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/TicketsBot-cloud/status-updates/internal/db"
+)
+
+// StatusBoard represents the pinned message kept up to date with the current status of a feed
+type StatusBoard struct {
+	Feed      string `json:"feed" db:"feed"`
+	ChannelId uint64 `json:"channel_id" db:"channel_id"`
+	MessageId uint64 `json:"message_id" db:"message_id"`
+	// ContentHash identifies the content the message was last rendered with, so it is only edited when it changes
+	ContentHash string    `json:"content_hash" db:"content_hash"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+func (b StatusBoard) Save() error {
+	_, err := db.Client.NamedExec(`INSERT INTO status_boards (feed, channel_id, message_id, content_hash, updated_at)
+		VALUES (:feed, :channel_id, :message_id, :content_hash, :updated_at)
+		ON CONFLICT (feed) DO UPDATE SET channel_id = EXCLUDED.channel_id, message_id = EXCLUDED.message_id,
+		content_hash = EXCLUDED.content_hash, updated_at = EXCLUDED.updated_at`, b)
+
+	if err != nil {
+		fmt.Printf("Error saving status board: %v\n", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetStatusBoard returns the status board of the given feed, if one has been created.
+func GetStatusBoard(feed string) (StatusBoard, bool, error) {
+	var board StatusBoard
+	if err := db.Client.Get(&board, "SELECT * FROM status_boards WHERE feed = $1", feed); err != nil {
+		if err == sql.ErrNoRows {
+			return StatusBoard{}, false, nil
+		}
+		fmt.Printf("Error retrieving status board: %v\n", err)
+		return StatusBoard{}, false, err
+	}
+
+	return board, true, nil
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/TicketsBot-cloud/status-updates/internal/db"
+	"github.com/lib/pq"
+)
+
+// Subscription routes the incidents of a feed to a channel, such as one in a partner guild
+type Subscription struct {
+	Feed      string `json:"feed" db:"feed"`
+	GuildId   uint64 `json:"guild_id" db:"guild_id"`
+	ChannelId uint64 `json:"channel_id" db:"channel_id"`
+	// RoleId is mentioned on new incidents, if set
+	RoleId uint64 `json:"role_id" db:"role_id"`
+	// Components limits the subscription to incidents affecting at least one of the given component IDs
+	Components      pq.StringArray `json:"components" db:"components"`
+	ShouldCrosspost bool           `json:"should_crosspost" db:"should_crosspost"`
+	// Locale is the locale messages are posted in. The feed's locale is used if empty.
+	Locale    string    `json:"locale" db:"locale"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// MatchesComponents reports whether an incident affecting the given component IDs should be announced.
+func (s Subscription) MatchesComponents(componentIds []string) bool {
+	if len(s.Components) == 0 {
+		return true
+	}
+
+	for _, id := range componentIds {
+		if slices.Contains(s.Components, id) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetSubscriptions returns every subscription to the given feed.
+func GetSubscriptions(feed string) ([]Subscription, error) {
+	var subscriptions []Subscription
+	if err := db.Client.Select(&subscriptions, "SELECT * FROM subscriptions WHERE feed = $1 ORDER BY created_at", feed); err != nil {
+		fmt.Printf("Error retrieving subscriptions: %v\n", err)
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
@@ -0,0 +1,272 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/TicketsBot-cloud/gdl/objects/interaction/component"
+	"github.com/TicketsBot-cloud/status-updates/internal/config"
+	"github.com/TicketsBot-cloud/status-updates/internal/i18n"
+	"github.com/TicketsBot-cloud/status-updates/internal/render"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// Mention line events, describing what a message announces
+const (
+	EventIncident    = "incident"
+	EventMaintenance = "maintenance"
+	EventUpdate      = "update"
+)
+
+// sectionSeparator splits the output of container templates into sections, divided by a separator line
+const sectionSeparator = "---"
+
+const defaultAnnouncementTemplate = `{{if .Incident.IsMaintenance -}}
+## 🔧 {{t .Locale "Scheduled Maintenance"}} - {{.Incident.Name}}
+---
+**{{t .Locale "Window:"}}** {{timestamp .Incident.ScheduledFor "F"}}{{if not .Incident.ScheduledUntil.IsZero}} - {{timestamp .Incident.ScheduledUntil "F"}}{{end}}
+{{.Countdown}}
+{{- else -}}
+## {{.Severity}} - {{.Incident.Name}}
+---
+{{- end}}
+
+{{.Updates}}
+{{- with .Components}}
+---
+**{{t $.Locale "Affected Components"}}**
+{{.}}
+{{- end}}
+
+{{t .Locale "Status: %s" (statusName .Locale .Incident.Status)}}`
+
+const defaultUpdateTemplate = `## {{if .Incident.IsMaintenance}}🔧 {{t .Locale "Scheduled Maintenance"}}{{else}}{{.Severity}}{{end}} - {{.Incident.Name}}
+---
+[{{timestamp .Update.DisplayAt "t"}}] {{.Body}}
+{{- with .Transitions}}
+---
+{{.}}
+{{- end}}
+
+{{t .Locale "Status: %s" (statusName .Locale .Update.Status)}}`
+
+const defaultResolutionTemplate = `## ✅ {{if .Incident.IsMaintenance}}{{t .Locale "Completed"}}{{else}}{{t .Locale "Resolved"}}{{end}} - {{.Incident.Name}}
+---
+{{if .Incident.IsMaintenance}}{{t .Locale "This maintenance has been completed. Thank you for your patience."}}{{else}}{{t .Locale "This incident has been resolved. Thank you for your patience."}}{{end}}`
+
+const defaultMentionTemplate = `-# {{if eq .Event "update"}}{{t .Locale "A new update has been posted"}}{{else if eq .Event "maintenance"}}{{t .Locale "Maintenance has been scheduled"}}{{else}}{{t .Locale "A new incident has been reported"}}{{end}}{{with .Mention}} {{.}}{{end}}`
+
+// TemplateData is the data available to message templates
+type TemplateData struct {
+	Incident Incident
+	// Update is the update being posted, for the update template
+	Update IncidentUpdate
+	// Severity is the label of the incident's severity
+	Severity string
+	// SeverityEmoji is the emoji of the incident's severity
+	SeverityEmoji string
+	// Updates is the incident's updates, rendered to fit within the announcement, for the announcement template
+	Updates string
+	// Body is the body of the update rendered as Discord markdown, for the update template
+	Body string
+	// Components lists the components currently affected by the incident, with their status
+	Components string
+	// Transitions lists the components affected by the update, with how their status changed, for the update template
+	Transitions string
+	// Countdown describes when a maintenance starts or ends
+	Countdown string
+	// Event is what the message announces, for the mention line: "incident", "maintenance" or "update"
+	Event string
+	// Mention mentions the role notified of the message, if any, for the mention line
+	Mention string
+	// Locale is the locale the message is rendered in, for use with the t, statusLabel and statusName functions
+	Locale string
+}
+
+// messageTemplates holds the templates used to render incident messages
+type messageTemplates struct {
+	announcement *template.Template
+	update       *template.Template
+	resolution   *template.Template
+	mention      *template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"title": cases.Title(language.English).String,
+	"timestamp": func(t time.Time, style string) string {
+		return fmt.Sprintf("<t:%d:%s>", t.Unix(), style)
+	},
+	"t":           i18n.T,
+	"statusLabel": StatusLabel,
+	"statusName":  StatusName,
+	"statusEmoji": StatusEmoji,
+	"markdown":    render.Markdown,
+}
+
+var templates = mustDefaultTemplates()
+
+func mustDefaultTemplates() messageTemplates {
+	loaded, err := loadTemplates(config.Templates{})
+	if err != nil {
+		panic(err)
+	}
+
+	return loaded
+}
+
+// LoadTemplates loads the configured message templates, falling back to the built-in template for any that are
+// unset. Each template is executed against sample data, so that broken templates are reported at startup rather than
+// when an incident is announced.
+func LoadTemplates(conf config.Templates) error {
+	loaded, err := loadTemplates(conf)
+	if err != nil {
+		return err
+	}
+
+	templates = loaded
+	return nil
+}
+
+func loadTemplates(conf config.Templates) (messageTemplates, error) {
+	var loaded messageTemplates
+	for _, t := range []struct {
+		name        string
+		path        string
+		defaultText string
+		dest        **template.Template
+	}{
+		{"announcement", conf.Announcement, defaultAnnouncementTemplate, &loaded.announcement},
+		{"update", conf.Update, defaultUpdateTemplate, &loaded.update},
+		{"resolution", conf.Resolution, defaultResolutionTemplate, &loaded.resolution},
+		{"mention", conf.Mention, defaultMentionTemplate, &loaded.mention},
+	} {
+		text := t.defaultText
+		if t.path != "" {
+			contents, err := os.ReadFile(t.path)
+			if err != nil {
+				return messageTemplates{}, fmt.Errorf("error reading %s template: %w", t.name, err)
+			}
+			text = string(contents)
+		}
+
+		parsed, err := template.New(t.name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return messageTemplates{}, fmt.Errorf("error parsing %s template: %w", t.name, err)
+		}
+
+		for _, data := range sampleTemplateData() {
+			if err := parsed.Execute(io.Discard, data); err != nil {
+				return messageTemplates{}, fmt.Errorf("error executing %s template: %w", t.name, err)
+			}
+		}
+
+		*t.dest = parsed
+	}
+
+	return loaded, nil
+}
+
+// sampleTemplateData returns data covering both incidents and maintenances, used to validate templates.
+func sampleTemplateData() []TemplateData {
+	now := time.Now()
+	update := IncidentUpdate{
+		ID:        "sample-update",
+		Body:      "We are investigating this issue.",
+		Status:    "investigating",
+		DisplayAt: now,
+		AffectedComponents: []AffectedComponent{
+			{Code: "sample-component", Name: "API", OldStatus: "operational", NewStatus: "major_outage"},
+		},
+	}
+
+	incident := Incident{
+		ID:              "sample-incident",
+		Name:            "Elevated error rates",
+		Status:          "investigating",
+		Impact:          "major",
+		Shortlink:       "https://stspg.io/sample",
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		Components:      []Component{{ID: "sample-component", Name: "API", Status: "major_outage"}},
+		IncidentUpdates: []IncidentUpdate{update},
+	}
+
+	maintenance := incident
+	maintenance.Name = "Database upgrade"
+	maintenance.Status = "scheduled"
+	maintenance.ScheduledFor = now.Add(time.Hour)
+	maintenance.ScheduledUntil = now.Add(2 * time.Hour)
+
+	var data []TemplateData
+	for _, i := range []Incident{incident, maintenance} {
+		for _, event := range []string{EventIncident, EventMaintenance, EventUpdate} {
+			d := i.templateData()
+			d.Update = update
+			d.Transitions = formatTransitions(update.AffectedComponents, d.Locale)
+			d.Body = render.Markdown(update.Body)
+			d.Updates = update.AsString()
+			d.Event = event
+			d.Mention = "<@&1>"
+			data = append(data, d)
+		}
+	}
+
+	return data
+}
+
+func (i Incident) templateData() TemplateData {
+	return TemplateData{
+		Incident:      i,
+		Severity:      i.GetSeverity(),
+		SeverityEmoji: StatusEmoji(i.Severity()),
+		Components:    fitComponentLines(componentStatusLines(i.Components, i.Locale), componentListBudget, i.Locale),
+		Countdown:     i.countdown(),
+		Locale:        i.Locale,
+	}
+}
+
+// execute renders the template, falling back to the built-in template if it fails. Templates are validated at
+// startup, so this only happens if a template fails on data unlike the sample data.
+func execute(t *template.Template, fallback string, data TemplateData) string {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		fmt.Printf("Error executing %s template: %v\n", t.Name(), err)
+
+		buf.Reset()
+		if err := template.Must(template.New(t.Name()).Funcs(templateFuncs).Parse(fallback)).Execute(&buf, data); err != nil {
+			fmt.Printf("Error executing built-in %s template: %v\n", t.Name(), err)
+		}
+	}
+
+	return buf.String()
+}
+
+// textSections splits the rendered output of a container template into text displays, divided by separators at lines
+// consisting only of "---". The output is truncated if it exceeds the text budget of a container.
+func textSections(rendered string) []component.Component {
+	rendered = truncate(rendered, textBudget())
+
+	var components []component.Component
+	for _, section := range strings.Split(rendered, "\n"+sectionSeparator+"\n") {
+		section = strings.TrimSpace(section)
+		if section == "" || section == sectionSeparator {
+			continue
+		}
+
+		if len(components) > 0 {
+			components = append(components, component.BuildSeparator(component.Separator{}))
+		}
+
+		components = append(components, component.BuildTextDisplay(component.TextDisplay{
+			Content: section,
+		}))
+	}
+
+	return components
+}
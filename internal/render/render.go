@@ -0,0 +1,259 @@
+// Package render converts status page content to Discord markdown.
+package render
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	// Discord only renders headings up to level 3
+	deepHeadingPattern = regexp.MustCompile(`(?m)^#{4,}\s+(.+?)\s*#*$`)
+	imagePattern       = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)[^)]*\)`)
+	autolinkPattern    = regexp.MustCompile(`<(https?://[^>\s]+)>`)
+	massMentionPattern = regexp.MustCompile(`@(everyone|here)`)
+	mentionPattern     = regexp.MustCompile(`<(@[!&]?|#)(\d+)>`)
+	urlPattern         = regexp.MustCompile(`https?://[^\s<>()]+`)
+	blankLinesPattern  = regexp.MustCompile(`\n{3,}`)
+	trailingSpace      = regexp.MustCompile(`[ \t]+\n`)
+)
+
+// Markdown converts the body of an incident update or postmortem, written in Markdown and/or a subset of HTML, to
+// Markdown that renders correctly in Discord. Links are preserved, and mentions are escaped so that they can never
+// ping anyone.
+func Markdown(body string) string {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+
+	// Markdown autolinks would otherwise be parsed as HTML tags
+	body = autolinkPattern.ReplaceAllString(body, "$1")
+
+	parent := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(body), parent)
+	if err == nil {
+		var r renderer
+		for _, node := range nodes {
+			r.render(node)
+		}
+		body = r.String()
+	}
+
+	body = deepHeadingPattern.ReplaceAllString(body, "**$1**")
+	body = imagePattern.ReplaceAllStringFunc(body, func(match string) string {
+		groups := imagePattern.FindStringSubmatch(match)
+		return link(groups[1], groups[2])
+	})
+	body = escapeMentionsOutsideLinks(body)
+	body = trailingSpace.ReplaceAllString(body, "\n")
+	body = blankLinesPattern.ReplaceAllString(body, "\n\n")
+
+	return strings.TrimSpace(body)
+}
+
+// EscapeMentions breaks up @everyone, @here, and user, role and channel mentions, so that they are shown as written
+// rather than mentioning anyone.
+func EscapeMentions(content string) string {
+	content = massMentionPattern.ReplaceAllString(content, "@\u200b$1")
+	return mentionPattern.ReplaceAllString(content, "<$1\u200b$2>")
+}
+
+// escapeMentionsOutsideLinks escapes mentions in content, leaving URLs untouched so that links keep working.
+func escapeMentionsOutsideLinks(content string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range urlPattern.FindAllStringIndex(content, -1) {
+		b.WriteString(EscapeMentions(content[last:loc[0]]))
+		b.WriteString(content[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(EscapeMentions(content[last:]))
+
+	return b.String()
+}
+
+type list struct {
+	ordered bool
+	index   int
+}
+
+// renderer writes HTML nodes as Discord markdown. Text is written as is, so Markdown in the body is preserved.
+type renderer struct {
+	strings.Builder
+	lists []list
+}
+
+func (r *renderer) render(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		// Whitespace between block elements, such as list items, is formatting rather than content
+		if strings.TrimSpace(n.Data) == "" && n.Parent != nil && isBlockContainer(n.Parent.DataAtom) {
+			return
+		}
+		r.WriteString(n.Data)
+	case html.ElementNode:
+		r.renderElement(n)
+	default:
+		r.renderChildren(n)
+	}
+}
+
+func (r *renderer) renderChildren(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		r.render(child)
+	}
+}
+
+// inner renders the children of the node on their own, for elements that must wrap or transform their content.
+func (r *renderer) inner(n *html.Node) string {
+	sub := renderer{lists: r.lists}
+	sub.renderChildren(n)
+	return sub.String()
+}
+
+func (r *renderer) renderElement(n *html.Node) {
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Head:
+	case atom.Br:
+		r.WriteString("\n")
+	case atom.P, atom.Div:
+		r.newline()
+		r.renderChildren(n)
+		r.WriteString("\n\n")
+	case atom.H1, atom.H2, atom.H3:
+		r.newline()
+		r.WriteString(strings.Repeat("#", int(n.Data[1]-'0')) + " " + strings.TrimSpace(r.inner(n)) + "\n")
+	case atom.H4, atom.H5, atom.H6:
+		r.newline()
+		r.WriteString("**" + strings.TrimSpace(r.inner(n)) + "**\n")
+	case atom.Strong, atom.B:
+		r.wrap(n, "**")
+	case atom.Em, atom.I:
+		r.wrap(n, "*")
+	case atom.U, atom.Ins:
+		r.wrap(n, "__")
+	case atom.S, atom.Strike, atom.Del:
+		r.wrap(n, "~~")
+	case atom.Code:
+		r.wrap(n, "`")
+	case atom.Pre:
+		r.newline()
+		r.WriteString("```\n" + strings.Trim(textContent(n), "\n") + "\n```\n")
+	case atom.A:
+		r.WriteString(link(r.inner(n), attr(n, "href")))
+	case atom.Img:
+		r.WriteString(link(attr(n, "alt"), attr(n, "src")))
+	case atom.Ul, atom.Ol:
+		r.newline()
+		r.lists = append(r.lists, list{ordered: n.DataAtom == atom.Ol})
+		r.renderChildren(n)
+		r.lists = r.lists[:len(r.lists)-1]
+		if len(r.lists) == 0 {
+			r.WriteString("\n")
+		}
+	case atom.Li:
+		r.newline()
+		marker := "-"
+		if len(r.lists) > 0 {
+			current := &r.lists[len(r.lists)-1]
+			current.index++
+			if current.ordered {
+				marker = fmt.Sprintf("%d.", current.index)
+			}
+		}
+
+		// Drop the blank lines left by whitespace around nested lists, which would otherwise split the list
+		var lines []string
+		for _, line := range strings.Split(r.inner(n), "\n") {
+			if line = strings.TrimRight(line, " \t"); strings.TrimSpace(line) != "" {
+				lines = append(lines, line)
+			}
+		}
+
+		indent := strings.Repeat("  ", max(len(r.lists)-1, 0))
+		content := strings.TrimSpace(strings.Join(lines, "\n"))
+		r.WriteString(indent + marker + " " + content + "\n")
+	case atom.Blockquote:
+		r.newline()
+		for _, line := range strings.Split(strings.TrimSpace(r.inner(n)), "\n") {
+			r.WriteString("> " + line + "\n")
+		}
+	case atom.Hr:
+		r.newline()
+		r.WriteString("\n")
+	default:
+		r.renderChildren(n)
+	}
+}
+
+// wrap renders the children of the node surrounded by the given markdown delimiter, keeping surrounding whitespace
+// outside of the delimiters so that Discord recognises them.
+func (r *renderer) wrap(n *html.Node, delimiter string) {
+	content := r.inner(n)
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		r.WriteString(content)
+		return
+	}
+
+	start := strings.Index(content, trimmed)
+	r.WriteString(content[:start] + delimiter + trimmed + delimiter + content[start+len(trimmed):])
+}
+
+// newline starts a new line, unless the output is empty or already at the start of a line.
+func (r *renderer) newline() {
+	if s := r.String(); s != "" && !strings.HasSuffix(s, "\n") {
+		r.WriteString("\n")
+	}
+}
+
+// link renders a markdown link. Discord only supports masked links to web pages, so other links are reduced to
+// their text.
+func link(text, href string) string {
+	text = strings.TrimSpace(text)
+	parsed, err := url.Parse(href)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return text
+	}
+
+	if text == "" || text == href {
+		return href
+	}
+
+	return "[" + strings.ReplaceAll(text, "]", "\\]") + "](" + href + ")"
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(textContent(child))
+	}
+
+	return b.String()
+}
+
+func isBlockContainer(a atom.Atom) bool {
+	switch a {
+	case atom.Ul, atom.Ol, atom.Table, atom.Thead, atom.Tbody, atom.Tr, atom.Blockquote:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,85 @@
+package source
+
+import (
+	"context"
+	"sync"
+
+	"github.com/TicketsBot-cloud/status-updates/internal/model"
+)
+
+// Fake is an in-memory IncidentSource, intended for tests and local development.
+type Fake struct {
+	name       string
+	mu         sync.RWMutex
+	incidents  map[string]model.Incident
+	components []model.Component
+}
+
+var _ IncidentSource = (*Fake)(nil)
+
+func NewFake(name string) *Fake {
+	return &Fake{
+		name:      name,
+		incidents: make(map[string]model.Incident),
+	}
+}
+
+func (f *Fake) Name() string {
+	return f.name
+}
+
+// SetIncident adds or replaces an incident.
+func (f *Fake) SetIncident(incident model.Incident) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.incidents[incident.ID] = incident
+}
+
+// RemoveIncident removes an incident, if it exists.
+func (f *Fake) RemoveIncident(incidentId string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.incidents, incidentId)
+}
+
+// SetComponents replaces every component.
+func (f *Fake) SetComponents(components []model.Component) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.components = components
+}
+
+// ListIncidents returns every incident, regardless of mode.
+func (f *Fake) ListIncidents(_ context.Context, _ SyncMode) ([]model.Incident, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	incidents := make([]model.Incident, 0, len(f.incidents))
+	for _, incident := range f.incidents {
+		incidents = append(incidents, incident)
+	}
+
+	return incidents, nil
+}
+
+func (f *Fake) GetIncident(_ context.Context, incidentId string) (model.Incident, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	incident, ok := f.incidents[incidentId]
+	if !ok {
+		return model.Incident{}, ErrIncidentNotFound
+	}
+
+	return incident, nil
+}
+
+func (f *Fake) ListComponents(_ context.Context) ([]model.Component, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return append([]model.Component(nil), f.components...), nil
+}
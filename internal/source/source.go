@@ -0,0 +1,39 @@
+// Package source defines the interface implemented by providers of incidents and components.
+package source
+
+import (
+	"context"
+	"errors"
+
+	"github.com/TicketsBot-cloud/status-updates/internal/model"
+)
+
+var (
+	// ErrUnavailable is wrapped by errors returned when a source is temporarily refusing requests, such as while a
+	// circuit breaker is open. Callers may skip the source without logging an error.
+	ErrUnavailable = errors.New("source is temporarily unavailable")
+	// ErrIncidentNotFound is returned by GetIncident when the source has no incident with the given ID.
+	ErrIncidentNotFound = errors.New("incident not found")
+)
+
+// SyncMode controls which incidents are returned by ListIncidents.
+type SyncMode int
+
+const (
+	// SyncRecent returns unresolved incidents along with the most recently created incidents
+	SyncRecent SyncMode = iota
+	// SyncFull returns every incident
+	SyncFull
+)
+
+// IncidentSource is a provider of incidents and components, such as a Statuspage page.
+type IncidentSource interface {
+	// Name uniquely identifies the source. Incidents are tagged with the name of the source they came from.
+	Name() string
+	// ListIncidents returns the incidents selected by mode.
+	ListIncidents(ctx context.Context, mode SyncMode) ([]model.Incident, error)
+	// GetIncident returns a single incident by ID.
+	GetIncident(ctx context.Context, incidentId string) (model.Incident, error)
+	// ListComponents returns every component.
+	ListComponents(ctx context.Context) ([]model.Component, error)
+}
@@ -0,0 +1,127 @@
+package statuspage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TicketsBot-cloud/status-updates/internal/config"
+	"github.com/TicketsBot-cloud/status-updates/internal/model"
+	"github.com/TicketsBot-cloud/status-updates/internal/source"
+	"go.uber.org/zap"
+)
+
+// PublicClient reads incidents and components from the public, unauthenticated API of any Statuspage hosted status
+// page, allowing status pages we do not own to be mirrored.
+type PublicClient struct {
+	logger    *zap.Logger
+	feed      config.Feed
+	baseUrl   string
+	transport *transport
+
+	mu       sync.Mutex
+	listed   []model.Incident // listed holds the incidents returned by the last call to ListIncidents
+	listedAt time.Time        // listedAt is when listed was fetched
+}
+
+// listReuseWindow is how long the incidents fetched by ListIncidents are reused to look up single incidents, which is
+// long enough to cover the lookups made during the same poll.
+const listReuseWindow = time.Minute
+
+// publicIncidentsResponse represents the response of /api/v2/incidents.json
+type publicIncidentsResponse struct {
+	Incidents []model.Incident `json:"incidents"`
+}
+
+// publicScheduledMaintenancesResponse represents the response of /api/v2/scheduled-maintenances.json
+type publicScheduledMaintenancesResponse struct {
+	ScheduledMaintenances []model.Incident `json:"scheduled_maintenances"`
+}
+
+// publicSummaryResponse represents the response of /api/v2/summary.json
+type publicSummaryResponse struct {
+	Components []model.Component `json:"components"`
+}
+
+var _ source.IncidentSource = (*PublicClient)(nil)
+
+func NewPublicClient(logger *zap.Logger, feed config.Feed) *PublicClient {
+	baseUrl := strings.TrimSuffix(feed.Url, "/")
+	if !strings.HasPrefix(baseUrl, "http://") && !strings.HasPrefix(baseUrl, "https://") {
+		baseUrl = "https://" + baseUrl
+	}
+
+	return &PublicClient{
+		logger:    logger,
+		feed:      feed,
+		baseUrl:   baseUrl,
+		transport: newTransport(logger, config.Conf),
+	}
+}
+
+// Name returns the name incidents from this client are tagged with.
+func (p *PublicClient) Name() string {
+	return p.feed.Name
+}
+
+// ListIncidents returns the most recent incidents and scheduled maintenances. The public API does not expose older
+// history, so the same incidents are returned regardless of mode.
+func (p *PublicClient) ListIncidents(ctx context.Context, _ source.SyncMode) ([]model.Incident, error) {
+	var incidents publicIncidentsResponse
+	if err := p.get(ctx, "incidents.json", &incidents); err != nil {
+		return nil, err
+	}
+
+	var maintenances publicScheduledMaintenancesResponse
+	if err := p.get(ctx, "scheduled-maintenances.json", &maintenances); err != nil {
+		return nil, err
+	}
+
+	all := append(incidents.Incidents, maintenances.ScheduledMaintenances...)
+
+	p.mu.Lock()
+	p.listed, p.listedAt = all, time.Now()
+	p.mu.Unlock()
+
+	return all, nil
+}
+
+// GetIncident returns a single incident by ID. The public API has no endpoint for a single incident, so it is looked
+// up among the most recent incidents and scheduled maintenances. The incidents fetched by a recent call to
+// ListIncidents are reused, rather than fetching them again for every incident looked up.
+func (p *PublicClient) GetIncident(ctx context.Context, incidentId string) (model.Incident, error) {
+	p.mu.Lock()
+	incidents, fresh := p.listed, time.Since(p.listedAt) < listReuseWindow
+	p.mu.Unlock()
+
+	if !fresh {
+		var err error
+		if incidents, err = p.ListIncidents(ctx, source.SyncRecent); err != nil {
+			return model.Incident{}, err
+		}
+	}
+
+	for _, incident := range incidents {
+		if incident.ID == incidentId {
+			return incident, nil
+		}
+	}
+
+	return model.Incident{}, fmt.Errorf("%w: %s", source.ErrIncidentNotFound, incidentId)
+}
+
+// ListComponents returns every component on the page.
+func (p *PublicClient) ListComponents(ctx context.Context) ([]model.Component, error) {
+	var summary publicSummaryResponse
+	if err := p.get(ctx, "summary.json", &summary); err != nil {
+		return nil, err
+	}
+
+	return summary.Components, nil
+}
+
+func (p *PublicClient) get(ctx context.Context, path string, out any) error {
+	return p.transport.get(ctx, fmt.Sprintf("%s/api/v2/%s", p.baseUrl, path), path, nil, out)
+}
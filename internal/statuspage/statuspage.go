@@ -1,64 +1,229 @@
 package statuspage
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"github.com/TicketsBot-cloud/status-updates/internal/config"
 	"github.com/TicketsBot-cloud/status-updates/internal/model"
+	"github.com/TicketsBot-cloud/status-updates/internal/source"
 	"go.uber.org/zap"
 )
 
+const (
+	baseUrl = "https://api.statuspage.io/v1"
+
+	// MaxPerPage is the largest page size accepted by the Statuspage API
+	MaxPerPage = 100
+	// RecentPerPage is the number of most recent incidents fetched by GetRecentIncidents
+	RecentPerPage = 25
+)
+
+// StatusPageClient reads incidents and components from a page we own using the authenticated manage API.
 type StatusPageClient struct {
-	logger *zap.Logger
-	config config.Config
+	logger    *zap.Logger
+	feed      config.Feed
+	transport *transport
 }
 
-func NewClient(logger *zap.Logger) StatusPageClient {
-	return StatusPageClient{
-		logger: logger,
-		config: config.Conf,
+func NewClient(logger *zap.Logger, feed config.Feed) *StatusPageClient {
+	return &StatusPageClient{
+		logger:    logger,
+		feed:      feed,
+		transport: newTransport(logger, config.Conf),
 	}
 }
 
-func (s *StatusPageClient) GetIncidents() ([]model.Incident, error) {
-	url := fmt.Sprintf("https://api.statuspage.io/v1/pages/%s/incidents", s.config.StatusPage.PageId)
+var _ source.IncidentSource = (*StatusPageClient)(nil)
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		s.logger.Error("Error creating request", zap.Error(err))
-		return nil, err
+// Name returns the name incidents from this client are tagged with.
+func (s *StatusPageClient) Name() string {
+	return s.feed.Name
+}
+
+// ListIncidents returns the incidents selected by mode.
+func (s *StatusPageClient) ListIncidents(ctx context.Context, mode source.SyncMode) ([]model.Incident, error) {
+	if mode == source.SyncFull {
+		return s.GetAllIncidents(ctx)
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("OAuth %s", s.config.StatusPage.ApiKey))
+	return s.GetRecentIncidents(ctx)
+}
+
+// ListComponents returns every component on the page.
+func (s *StatusPageClient) ListComponents(ctx context.Context) ([]model.Component, error) {
+	return getAllPages[model.Component](ctx, s, "components")
+}
+
+// GetIncidentsPage returns a single page of incidents, newest first. Pages are numbered from 1.
+func (s *StatusPageClient) GetIncidentsPage(ctx context.Context, page, perPage int) ([]model.Incident, error) {
+	return getPage[model.Incident](ctx, s, "incidents", page, perPage)
+}
+
+// GetAllIncidents returns every incident on the page, following pagination until the last page is reached.
+func (s *StatusPageClient) GetAllIncidents(ctx context.Context) ([]model.Incident, error) {
+	return getAllPages[model.Incident](ctx, s, "incidents")
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// GetRecentIncidents returns every unresolved incident and every upcoming or in progress maintenance, along with the
+// most recently created incidents so that recently resolved incidents are also included. Incidents are de-duplicated
+// by ID.
+func (s *StatusPageClient) GetRecentIncidents(ctx context.Context) ([]model.Incident, error) {
+	unresolved, err := s.GetUnresolvedIncidents(ctx)
 	if err != nil {
-		s.logger.Error("Error making request", zap.Error(err))
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		s.logger.Error("Unexpected status code", zap.Int("status_code", resp.StatusCode))
+	upcoming, err := s.GetUpcomingIncidents(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	maintenance, err := s.GetActiveMaintenanceIncidents(ctx)
 	if err != nil {
-		s.logger.Error("Error reading response body", zap.Error(err))
 		return nil, err
 	}
 
-	var incidents []model.Incident
-	err = json.Unmarshal(body, &incidents)
+	recent, err := s.GetIncidentsPage(ctx, 1, RecentPerPage)
 	if err != nil {
-		s.logger.Error("Error decoding JSON", zap.Error(err))
 		return nil, err
 	}
 
+	all := append(append(append(unresolved, upcoming...), maintenance...), recent...)
+	seen := make(map[string]bool, len(all))
+	incidents := make([]model.Incident, 0, len(all))
+	for _, incident := range all {
+		if seen[incident.ID] {
+			continue
+		}
+
+		seen[incident.ID] = true
+		incidents = append(incidents, incident)
+	}
+
 	return incidents, nil
 }
+
+// GetUnresolvedIncidents returns every incident that has not yet been resolved.
+func (s *StatusPageClient) GetUnresolvedIncidents(ctx context.Context) ([]model.Incident, error) {
+	return getAllPages[model.Incident](ctx, s, "incidents/unresolved")
+}
+
+// GetScheduledIncidents returns every scheduled maintenance.
+func (s *StatusPageClient) GetScheduledIncidents(ctx context.Context) ([]model.Incident, error) {
+	return getAllPages[model.Incident](ctx, s, "incidents/scheduled")
+}
+
+// GetUpcomingIncidents returns every scheduled maintenance that has not yet started.
+func (s *StatusPageClient) GetUpcomingIncidents(ctx context.Context) ([]model.Incident, error) {
+	return getAllPages[model.Incident](ctx, s, "incidents/upcoming")
+}
+
+// GetActiveMaintenanceIncidents returns every scheduled maintenance that is currently in progress.
+func (s *StatusPageClient) GetActiveMaintenanceIncidents(ctx context.Context) ([]model.Incident, error) {
+	return getAllPages[model.Incident](ctx, s, "incidents/active_maintenance")
+}
+
+// GetIncident returns a single incident by ID.
+func (s *StatusPageClient) GetIncident(ctx context.Context, incidentId string) (model.Incident, error) {
+	var incident model.Incident
+	if err := s.get(ctx, fmt.Sprintf("incidents/%s", url.PathEscape(incidentId)), nil, &incident); err != nil {
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			return model.Incident{}, fmt.Errorf("%w: %s", source.ErrIncidentNotFound, incidentId)
+		}
+
+		return model.Incident{}, err
+	}
+
+	return incident, nil
+}
+
+// CreateIncident creates a new incident on the page.
+func (s *StatusPageClient) CreateIncident(ctx context.Context, data model.IncidentRequest) (model.Incident, error) {
+	var incident model.Incident
+	if err := s.send(ctx, http.MethodPost, "incidents", incidentRequestBody{Incident: data}, &incident); err != nil {
+		return model.Incident{}, err
+	}
+
+	return incident, nil
+}
+
+// UpdateIncident updates an existing incident, posting a new incident update if a body is provided.
+func (s *StatusPageClient) UpdateIncident(ctx context.Context, incidentId string, data model.IncidentRequest) (model.Incident, error) {
+	var incident model.Incident
+	if err := s.send(ctx, http.MethodPatch, fmt.Sprintf("incidents/%s", url.PathEscape(incidentId)), incidentRequestBody{Incident: data}, &incident); err != nil {
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			return model.Incident{}, fmt.Errorf("%w: %s", source.ErrIncidentNotFound, incidentId)
+		}
+
+		return model.Incident{}, err
+	}
+
+	return incident, nil
+}
+
+// incidentRequestBody wraps an incident request as expected by the Statuspage API
+type incidentRequestBody struct {
+	Incident model.IncidentRequest `json:"incident"`
+}
+
+// getAllPages follows pagination on the given path until the last page is reached.
+func getAllPages[T any](ctx context.Context, s *StatusPageClient, path string) ([]T, error) {
+	var items []T
+	for page := 1; ; page++ {
+		batch, err := getPage[T](ctx, s, path, page, MaxPerPage)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, batch...)
+
+		if len(batch) < MaxPerPage {
+			return items, nil
+		}
+	}
+}
+
+func getPage[T any](ctx context.Context, s *StatusPageClient, path string, page, perPage int) ([]T, error) {
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(page))
+	query.Set("per_page", strconv.Itoa(perPage))
+
+	var items []T
+	if err := s.get(ctx, path, query, &items); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// get performs a GET request against the given path, relative to the configured page, and decodes the JSON response
+// into out.
+func (s *StatusPageClient) get(ctx context.Context, path string, query url.Values, out any) error {
+	endpoint := fmt.Sprintf("%s/pages/%s/%s", baseUrl, s.feed.PageId, path)
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", fmt.Sprintf("OAuth %s", s.feed.ApiKey))
+
+	return s.transport.get(ctx, endpoint, path, header, out)
+}
+
+// send performs a request with a JSON body against the given path, relative to the configured page, and decodes the
+// JSON response into out.
+func (s *StatusPageClient) send(ctx context.Context, method, path string, body, out any) error {
+	endpoint := fmt.Sprintf("%s/pages/%s/%s", baseUrl, s.feed.PageId, path)
+
+	header := http.Header{}
+	header.Set("Authorization", fmt.Sprintf("OAuth %s", s.feed.ApiKey))
+
+	return s.transport.send(ctx, method, endpoint, path, header, body, out)
+}
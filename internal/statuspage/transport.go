@@ -0,0 +1,306 @@
+package statuspage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/TicketsBot-cloud/status-updates/internal/config"
+	"github.com/TicketsBot-cloud/status-updates/internal/source"
+	"go.uber.org/zap"
+)
+
+const (
+	// baseBackoff is the delay before the first retry, doubled for each subsequent attempt
+	baseBackoff = 500 * time.Millisecond
+	// maxBackoff caps the delay between retries, including delays requested by Statuspage
+	maxBackoff = 30 * time.Second
+	// maxBackoffShift is the most times baseBackoff is doubled, which is already beyond maxBackoff
+	maxBackoffShift = 16
+	// statusEnhanceYourCalm is returned by Statuspage, alongside 429, when rate limited
+	statusEnhanceYourCalm = 420
+)
+
+// transport performs requests against Statuspage, retrying transient failures of GET requests with backoff and
+// pausing requests via a circuit breaker after repeated failures.
+type transport struct {
+	logger         *zap.Logger
+	httpClient     *http.Client
+	breaker        *circuitBreaker
+	requestTimeout time.Duration
+	maxRetries     int
+}
+
+func newTransport(logger *zap.Logger, conf config.Config) *transport {
+	return &transport{
+		logger:         logger,
+		httpClient:     &http.Client{},
+		breaker:        newCircuitBreaker(logger, conf.StatusPage.CircuitBreakerThreshold, conf.StatusPage.CircuitBreakerCooldown),
+		requestTimeout: conf.StatusPage.RequestTimeout,
+		maxRetries:     conf.StatusPage.MaxRetries,
+	}
+}
+
+// get performs a GET request against endpoint with the given headers and decodes the JSON response into out. path is
+// only used to identify the request in logs and errors.
+func (t *transport) get(ctx context.Context, endpoint, path string, header http.Header, out any) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		if !t.breaker.allow() {
+			return ErrCircuitOpen
+		}
+
+		err = t.doRequest(ctx, http.MethodGet, endpoint, path, header, nil, out)
+		if err == nil {
+			t.breaker.recordSuccess()
+			return nil
+		}
+
+		if !isRetryable(err) {
+			return err
+		}
+
+		t.breaker.recordFailure()
+
+		if attempt >= t.maxRetries {
+			return err
+		}
+
+		delay := backoff(attempt, err)
+		t.logger.Warn("Statuspage request failed, retrying", zap.String("path", path), zap.Int("attempt", attempt+1), zap.Duration("delay", delay), zap.Error(err))
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// send performs a request with a JSON body against endpoint and decodes the JSON response into out. Requests that
+// modify data are not idempotent, so they are attempted only once.
+func (t *transport) send(ctx context.Context, method, endpoint, path string, header http.Header, body, out any) error {
+	if !t.breaker.allow() {
+		return ErrCircuitOpen
+	}
+
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	err = t.doRequest(ctx, method, endpoint, path, header, encoded, out)
+	if err == nil {
+		t.breaker.recordSuccess()
+	} else if isRetryable(err) {
+		t.breaker.recordFailure()
+	}
+
+	return err
+}
+
+// doRequest performs a single attempt of a request, bounded by the configured request timeout. An attempt that times
+// out while ctx is still live returns errAttemptTimeout, as it is a sign of Statuspage being unhealthy rather than of
+// the caller giving up.
+func (t *transport) doRequest(ctx context.Context, method, endpoint, path string, header http.Header, body []byte, out any) error {
+	attemptCtx, cancel := context.WithTimeout(ctx, t.requestTimeout)
+	defer cancel()
+
+	err := t.attempt(attemptCtx, method, endpoint, path, header, body, out)
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s: %w", errAttemptTimeout, t.requestTimeout, err)
+	}
+
+	return err
+}
+
+// attempt performs a request without any timeout of its own.
+func (t *transport) attempt(ctx context.Context, method, endpoint, path string, header http.Header, body []byte, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
+	if err != nil {
+		return err
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &StatusError{
+			StatusCode: resp.StatusCode,
+			Path:       path,
+			Body:       string(respBody),
+			RetryAfter: parseRetryAfter(resp.Header, time.Now()),
+		}
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		t.logger.Error("Error decoding JSON", zap.String("path", path), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// errAttemptTimeout is returned when a single attempt of a request exceeds the request timeout.
+var errAttemptTimeout = errors.New("statuspage request timed out")
+
+// ErrCircuitOpen is returned without making a request while the circuit breaker is open.
+var ErrCircuitOpen = fmt.Errorf("statuspage circuit breaker is open: %w", source.ErrUnavailable)
+
+// StatusError is returned when Statuspage responds with an unexpected HTTP status code.
+type StatusError struct {
+	StatusCode int
+	Path       string
+	Body       string
+	RetryAfter time.Duration // RetryAfter is the delay requested by Statuspage, if any
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("statuspage returned status %d for %s: %s", e.StatusCode, e.Path, e.Body)
+}
+
+// IsRateLimited reports whether the request was rejected due to rate limiting.
+func (e *StatusError) IsRateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == statusEnhanceYourCalm
+}
+
+// IsRetryable reports whether the request may succeed if retried.
+func (e *StatusError) IsRetryable() bool {
+	return e.IsRateLimited() || e.StatusCode >= 500
+}
+
+// isRetryable reports whether err is a transient failure that should be retried and counted by the circuit breaker.
+func isRetryable(err error) bool {
+	if errors.Is(err, errAttemptTimeout) {
+		return true
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.IsRetryable()
+	}
+
+	// Network errors
+	return true
+}
+
+// backoff returns how long to wait before the given retry attempt, starting from 0. Delays requested by Statuspage
+// take precedence, otherwise exponential backoff with full jitter is used.
+func backoff(attempt int, err error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return min(statusErr.RetryAfter, maxBackoff)
+	}
+
+	// Clamp the shift, as large attempts would overflow the duration
+	ceiling := min(baseBackoff<<min(attempt, maxBackoffShift), maxBackoff)
+	return rand.N(ceiling) + 1
+}
+
+// parseRetryAfter returns the delay requested by the Retry-After header, falling back to the X-RateLimit-Reset
+// header, which holds the Unix time at which the rate limit resets.
+func parseRetryAfter(header http.Header, now time.Time) time.Duration {
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+
+		if at, err := http.ParseTime(value); err == nil {
+			return at.Sub(now)
+		}
+	}
+
+	if value := header.Get("X-RateLimit-Reset"); value != "" {
+		if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return time.Unix(unix, 0).Sub(now)
+		}
+	}
+
+	return 0
+}
+
+// circuitBreaker stops requests being made for a cooldown period after too many consecutive failures, so that a
+// Statuspage outage does not result in every run retrying and logging errors.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	logger    *zap.Logger
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+}
+
+func newCircuitBreaker(logger *zap.Logger, threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		logger:    logger,
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// allow reports whether a request may be made. Once the cooldown has elapsed, requests are allowed through again, and
+// a single further failure re-opens the circuit.
+func (c *circuitBreaker) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return time.Now().After(c.openUntil)
+}
+
+func (c *circuitBreaker) recordSuccess() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.failures >= c.threshold {
+		c.logger.Info("Statuspage circuit breaker closed")
+	}
+
+	c.failures = 0
+}
+
+func (c *circuitBreaker) recordFailure() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failures++
+	if c.failures >= c.threshold {
+		c.openUntil = time.Now().Add(c.cooldown)
+
+		if c.failures == c.threshold {
+			c.logger.Warn("Statuspage circuit breaker opened", zap.Int("failures", c.failures), zap.Duration("cooldown", c.cooldown))
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// minSplitLimit is the smallest limit SplitMessage splits at, so that it always makes progress and a tight budget does
+// not split content into a large number of tiny chunks.
+const minSplitLimit = 100
+
+// SplitMessage splits content into chunks of at most limit characters, preferring to break between paragraphs, then
+// between lines, then between words. Limits below minSplitLimit are raised to it.
+func SplitMessage(content string, limit int) []string {
+	limit = max(limit, minSplitLimit)
+
+	var chunks []string
+	for utf8.RuneCountInString(content) > limit {
+		cut := splitIndex(content, limit)
+		if chunk := strings.TrimRight(content[:cut], " \n"); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		content = strings.TrimLeft(content[cut:], " \n")
+	}
+
+	if content != "" {
+		chunks = append(chunks, content)
+	}
+
+	return chunks
+}
+
+// splitIndex returns the byte offset at which to split content so that the first part is at most limit characters.
+func splitIndex(content string, limit int) int {
+	end := len(content)
+	count := 0
+	for idx := range content {
+		if count == limit {
+			end = idx
+			break
+		}
+		count++
+	}
+
+	window := content[:end]
+	for _, separator := range []string{"\n\n", "\n", " "} {
+		if idx := strings.LastIndex(window, separator); idx > 0 {
+			return idx
+		}
+	}
+
+	return end
+}